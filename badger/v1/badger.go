@@ -16,7 +16,9 @@ import (
 
 // DB is a wrapper over *badger.DB,
 type DB struct {
-	db *badger.DB
+	db             *badger.DB
+	valueValidator database.ValueValidator
+	notifier       *database.Notifier
 }
 
 // Open opens or creates a BoltDB database in the given path.
@@ -49,6 +51,8 @@ func (db *DB) Open(dir string, opt ...database.Option) (err error) {
 		bo.ValueDir = dir
 	}
 
+	db.valueValidator = opts.ValueValidator
+	db.notifier = database.NewNotifier(opts)
 	db.db, err = badger.Open(bo)
 	return errors.Wrap(err, "error opening Badger database")
 }
@@ -63,11 +67,15 @@ func (db *DB) Close() error {
 func (db *DB) CreateTable(bucket []byte) error {
 	bk, err := badgerEncode(bucket)
 	if err != nil {
-		return err
+		return &database.OpError{Op: "create-table", Bucket: bucket, Err: err}
 	}
-	return db.db.Update(func(txn *badger.Txn) error {
-		return errors.Wrapf(txn.Set(bk, []byte{}), "failed to create %s/", bucket)
+	err = db.db.Update(func(txn *badger.Txn) error {
+		return errors.WithStack(txn.Set(bk, []byte{}))
 	})
+	if err != nil {
+		return &database.OpError{Op: "create-table", Bucket: bucket, Err: err}
+	}
+	return nil
 }
 
 // DeleteTable deletes a root or embedded bucket. Returns an error if the
@@ -76,7 +84,7 @@ func (db *DB) DeleteTable(bucket []byte) error {
 	var tableExists bool
 	prefix, err := badgerEncode(bucket)
 	if err != nil {
-		return err
+		return &database.OpError{Op: "delete-table", Bucket: bucket, Err: err}
 	}
 	deleteKeys := func(keysForDelete [][]byte) error {
 		if err := db.db.Update(func(txn *badger.Txn) error {
@@ -120,12 +128,15 @@ func (db *DB) DeleteTable(bucket []byte) error {
 			}
 		}
 		if !tableExists {
-			return errors.Wrapf(database.ErrNotFound, "table %s does not exist", bucket)
+			return errors.Wrap(database.ErrNotFound, "table does not exist")
 		}
 
 		return nil
 	})
-	return err
+	if err != nil {
+		return &database.OpError{Op: "delete-table", Bucket: bucket, Err: err}
+	}
+	return nil
 }
 
 // Compact triggers a value log garbage collection.
@@ -138,9 +149,9 @@ func badgerGet(txn *badger.Txn, key []byte) ([]byte, error) {
 	item, err := txn.Get(key)
 	switch {
 	case errors.Is(err, badger.ErrKeyNotFound):
-		return nil, errors.Wrapf(database.ErrNotFound, "key %s not found", key)
+		return nil, errors.Wrap(database.ErrNotFound, "key not found")
 	case err != nil:
-		return nil, errors.Wrapf(err, "failed to get key %s", key)
+		return nil, errors.Wrap(err, "failed to get key")
 	default:
 		val, err := item.ValueCopy(nil)
 		if err != nil {
@@ -154,12 +165,15 @@ func badgerGet(txn *badger.Txn, key []byte) ([]byte, error) {
 func (db *DB) Get(bucket, key []byte) (ret []byte, err error) {
 	bk, err := toBadgerKey(bucket, key)
 	if err != nil {
-		return nil, errors.Wrapf(err, "error converting %s/%s to badgerKey", bucket, key)
+		return nil, &database.OpError{Op: "get", Bucket: bucket, Key: key, Err: errors.Wrap(err, "error converting to badgerKey")}
 	}
 	err = db.db.View(func(txn *badger.Txn) error {
 		ret, err = badgerGet(txn, bk)
 		return err
 	})
+	if err != nil {
+		return nil, &database.OpError{Op: "get", Bucket: bucket, Key: key, Err: err}
+	}
 	return
 }
 
@@ -167,22 +181,35 @@ func (db *DB) Get(bucket, key []byte) (ret []byte, err error) {
 func (db *DB) Set(bucket, key, value []byte) error {
 	bk, err := toBadgerKey(bucket, key)
 	if err != nil {
-		return errors.Wrapf(err, "error converting %s/%s to badgerKey", bucket, key)
+		return &database.OpError{Op: "set", Bucket: bucket, Key: key, Err: errors.Wrap(err, "error converting to badgerKey")}
 	}
-	return db.db.Update(func(txn *badger.Txn) error {
-		return errors.Wrapf(txn.Set(bk, value), "failed to set %s/%s", bucket, key)
+	if err := db.valueValidator.Validate(bucket, key, value); err != nil {
+		return &database.OpError{Op: "set", Bucket: bucket, Key: key, Err: err}
+	}
+	err = db.db.Update(func(txn *badger.Txn) error {
+		return errors.WithStack(txn.Set(bk, value))
 	})
+	if err != nil {
+		return &database.OpError{Op: "set", Bucket: bucket, Key: key, Err: err}
+	}
+	db.notifier.Notify(database.Set, bucket, key, value)
+	return nil
 }
 
 // Del deletes the value stored in the given bucked and key.
 func (db *DB) Del(bucket, key []byte) error {
 	bk, err := toBadgerKey(bucket, key)
 	if err != nil {
-		return errors.Wrapf(err, "error converting %s/%s to badgerKey", bucket, key)
+		return &database.OpError{Op: "delete", Bucket: bucket, Key: key, Err: errors.Wrap(err, "error converting to badgerKey")}
 	}
-	return db.db.Update(func(txn *badger.Txn) error {
-		return errors.Wrapf(txn.Delete(bk), "failed to delete %s/%s", bucket, key)
+	err = db.db.Update(func(txn *badger.Txn) error {
+		return errors.WithStack(txn.Delete(bk))
 	})
+	if err != nil {
+		return &database.OpError{Op: "delete", Bucket: bucket, Key: key, Err: err}
+	}
+	db.notifier.Notify(database.Delete, bucket, key, nil)
+	return nil
 }
 
 // List returns the full list of entries in a bucket.
@@ -224,11 +251,14 @@ func (db *DB) List(bucket []byte) ([]*database.Entry, error) {
 			})
 		}
 		if !tableExists {
-			return errors.Wrapf(database.ErrNotFound, "bucket %s not found", bucket)
+			return errors.Wrap(database.ErrNotFound, "bucket not found")
 		}
 		return nil
 	})
-	return entries, err
+	if err != nil {
+		return nil, &database.OpError{Op: "list", Bucket: bucket, Err: err}
+	}
+	return entries, nil
 }
 
 // CmpAndSwap modifies the value at the given bucket and key (to newValue)
@@ -236,7 +266,10 @@ func (db *DB) List(bucket []byte) ([]*database.Entry, error) {
 func (db *DB) CmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool, error) {
 	bk, err := toBadgerKey(bucket, key)
 	if err != nil {
-		return nil, false, err
+		return nil, false, &database.OpError{Op: "cmpandswap", Bucket: bucket, Key: key, Err: errors.Wrap(err, "error converting to badgerKey")}
+	}
+	if err := db.valueValidator.Validate(bucket, key, newValue); err != nil {
+		return nil, false, &database.OpError{Op: "cmpandswap", Bucket: bucket, Key: key, Err: err}
 	}
 
 	badgerTxn := db.db.NewTransaction(true)
@@ -245,11 +278,12 @@ func (db *DB) CmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool,
 	val, swapped, err := cmpAndSwap(badgerTxn, bk, oldValue, newValue)
 	switch {
 	case err != nil:
-		return nil, false, err
+		return nil, false, &database.OpError{Op: "cmpandswap", Bucket: bucket, Key: key, Err: err}
 	case swapped:
 		if err := badgerTxn.Commit(); err != nil {
-			return nil, false, errors.Wrapf(err, "failed to commit badger transaction")
+			return nil, false, &database.OpError{Op: "cmpandswap", Bucket: bucket, Key: key, Err: errors.Wrapf(err, "failed to commit badger transaction")}
 		}
+		db.notifier.Notify(database.CmpAndSwap, bucket, key, newValue)
 		return val, swapped, nil
 	default:
 		return val, swapped, err
@@ -267,17 +301,18 @@ func cmpAndSwap(badgerTxn *badger.Txn, bk, oldValue, newValue []byte) ([]byte, b
 	}
 
 	if err := badgerTxn.Set(bk, newValue); err != nil {
-		return current, false, errors.Wrapf(err, "failed to set %s", bk)
+		return current, false, errors.Wrap(err, "failed to set key")
 	}
 	return newValue, true, nil
 }
 
 // Update performs multiple commands on one read-write transaction.
 func (db *DB) Update(txn *database.Tx) error {
-	return db.db.Update(func(badgerTxn *badger.Txn) (err error) {
+	err := db.db.Update(func(badgerTxn *badger.Txn) (err error) {
 		for _, q := range txn.Operations {
 			switch q.Cmd {
 			case database.CreateTable:
+				// CreateTable and DeleteTable already return an OpError.
 				if err := db.CreateTable(q.Bucket); err != nil {
 					return err
 				}
@@ -290,34 +325,44 @@ func (db *DB) Update(txn *database.Tx) error {
 			}
 			bk, err := toBadgerKey(q.Bucket, q.Key)
 			if err != nil {
-				return err
+				return database.TxOpError(q, errors.Wrap(err, "error converting to badgerKey"))
 			}
 			switch q.Cmd {
 			case database.Get:
 				if q.Result, err = badgerGet(badgerTxn, bk); err != nil {
-					return errors.Wrapf(err, "failed to get %s/%s", q.Bucket, q.Key)
+					return database.TxOpError(q, err)
 				}
 			case database.Set:
+				if err := db.valueValidator.Validate(q.Bucket, q.Key, q.Value); err != nil {
+					return database.TxOpError(q, err)
+				}
 				if err := badgerTxn.Set(bk, q.Value); err != nil {
-					return errors.Wrapf(err, "failed to set %s/%s", q.Bucket, q.Key)
+					return database.TxOpError(q, errors.WithStack(err))
 				}
 			case database.Delete:
 				if err = badgerTxn.Delete(bk); err != nil {
-					return errors.Wrapf(err, "failed to delete %s/%s", q.Bucket, q.Key)
+					return database.TxOpError(q, errors.WithStack(err))
 				}
 			case database.CmpAndSwap:
+				if err := db.valueValidator.Validate(q.Bucket, q.Key, q.Value); err != nil {
+					return database.TxOpError(q, err)
+				}
 				q.Result, q.Swapped, err = cmpAndSwap(badgerTxn, bk, q.CmpValue, q.Value)
 				if err != nil {
-					return errors.Wrapf(err, "failed to CmpAndSwap %s/%s", q.Bucket, q.Key)
+					return database.TxOpError(q, err)
 				}
 			case database.CmpOrRollback:
-				return database.ErrOpNotSupported
+				return database.TxOpError(q, database.ErrOpNotSupported)
 			default:
-				return database.ErrOpNotSupported
+				return database.TxOpError(q, database.ErrOpNotSupported)
 			}
 		}
 		return nil
 	})
+	if err == nil {
+		db.notifier.NotifyTx(txn)
+	}
+	return err
 }
 
 // toBadgerKey returns the Badger database key using the following algorithm:
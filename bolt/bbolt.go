@@ -16,7 +16,9 @@ var boltDBSep = []byte("/")
 
 // DB is a wrapper over bolt.DB,
 type DB struct {
-	db *bolt.DB
+	db             *bolt.DB
+	valueValidator database.ValueValidator
+	notifier       *database.Notifier
 }
 
 type boltBucket interface {
@@ -34,6 +36,8 @@ func (db *DB) Open(dataSourceName string, opt ...database.Option) (err error) {
 			return err
 		}
 	}
+	db.valueValidator = opts.ValueValidator
+	db.notifier = database.NewNotifier(opts)
 	db.db, err = bolt.Open(dataSourceName, 0600, &bolt.Options{Timeout: 5 * time.Second})
 	return errors.WithStack(err)
 }
@@ -45,17 +49,25 @@ func (db *DB) Close() error {
 
 // CreateTable creates a bucket or an embedded bucket if it does not exists.
 func (db *DB) CreateTable(bucket []byte) error {
-	return db.db.Update(func(tx *bolt.Tx) error {
+	err := db.db.Update(func(tx *bolt.Tx) error {
 		return db.createBucket(tx, bucket)
 	})
+	if err != nil {
+		return &database.OpError{Op: "create-table", Bucket: bucket, Err: err}
+	}
+	return nil
 }
 
 // DeleteTable deletes a root or embedded bucket. Returns an error if the
 // bucket cannot be found or if the key represents a non-bucket value.
 func (db *DB) DeleteTable(bucket []byte) error {
-	return db.db.Update(func(tx *bolt.Tx) error {
+	err := db.db.Update(func(tx *bolt.Tx) error {
 		return db.deleteBucket(tx, bucket)
 	})
+	if err != nil {
+		return &database.OpError{Op: "delete-table", Bucket: bucket, Err: err}
+	}
+	return nil
 }
 
 // Get returns the value stored in the given bucked and key.
@@ -74,29 +86,45 @@ func (db *DB) Get(bucket, key []byte) (ret []byte, err error) {
 		ret = cloneBytes(ret)
 		return nil
 	})
+	if err != nil {
+		return nil, &database.OpError{Op: "get", Bucket: bucket, Key: key, Err: err}
+	}
 	return
 }
 
 // Set stores the given value on bucket and key.
 func (db *DB) Set(bucket, key, value []byte) error {
-	return db.db.Update(func(tx *bolt.Tx) error {
+	if err := db.valueValidator.Validate(bucket, key, value); err != nil {
+		return &database.OpError{Op: "set", Bucket: bucket, Key: key, Err: err}
+	}
+	err := db.db.Update(func(tx *bolt.Tx) error {
 		b, err := db.getBucket(tx, bucket)
 		if err != nil {
 			return err
 		}
 		return errors.WithStack(b.Put(key, value))
 	})
+	if err != nil {
+		return &database.OpError{Op: "set", Bucket: bucket, Key: key, Err: err}
+	}
+	db.notifier.Notify(database.Set, bucket, key, value)
+	return nil
 }
 
 // Del deletes the value stored in the given bucked and key.
 func (db *DB) Del(bucket, key []byte) error {
-	return db.db.Update(func(tx *bolt.Tx) error {
+	err := db.db.Update(func(tx *bolt.Tx) error {
 		b, err := db.getBucket(tx, bucket)
 		if err != nil {
 			return err
 		}
 		return errors.WithStack(b.Delete(key))
 	})
+	if err != nil {
+		return &database.OpError{Op: "delete", Bucket: bucket, Key: key, Err: err}
+	}
+	db.notifier.Notify(database.Delete, bucket, key, nil)
+	return nil
 }
 
 // List returns the full list of entries in a bucket.
@@ -118,12 +146,30 @@ func (db *DB) List(bucket []byte) ([]*database.Entry, error) {
 		}
 		return nil
 	})
-	return entries, err
+	if err != nil {
+		return nil, &database.OpError{Op: "list", Bucket: bucket, Err: err}
+	}
+	return entries, nil
 }
 
 // CmpAndSwap modifies the value at the given bucket and key (to newValue)
 // only if the existing (current) value matches oldValue.
 func (db *DB) CmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool, error) {
+	if err := db.valueValidator.Validate(bucket, key, newValue); err != nil {
+		return nil, false, &database.OpError{Op: "cmpandswap", Bucket: bucket, Key: key, Err: err}
+	}
+	val, swapped, err := db.cmpAndSwap(bucket, key, oldValue, newValue)
+	if err != nil {
+		return nil, false, &database.OpError{Op: "cmpandswap", Bucket: bucket, Key: key, Err: err}
+	}
+	if swapped {
+		db.notifier.Notify(database.CmpAndSwap, bucket, key, newValue)
+	}
+	return val, swapped, nil
+}
+
+// cmpAndSwap is a helper for the CmpAndSwap method.
+func (db *DB) cmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool, error) {
 	boltTx, err := db.db.Begin(true)
 	if err != nil {
 		return nil, false, errors.Wrap(err, "error creating Bolt transaction")
@@ -131,14 +177,17 @@ func (db *DB) CmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool,
 
 	boltBucket := boltTx.Bucket(bucket)
 	if boltBucket == nil {
-		return nil, false, errors.Errorf("failed to get bucket %s", bucket)
+		if err := boltTx.Rollback(); err != nil {
+			return nil, false, errors.Wrap(err, "failed to rollback CmpAndSwap transaction")
+		}
+		return nil, false, errors.Wrap(database.ErrNotFound, "bucket does not exist")
 	}
 
 	val, swapped, err := cmpAndSwap(boltBucket, key, oldValue, newValue)
 	switch {
 	case err != nil:
 		if err := boltTx.Rollback(); err != nil {
-			return nil, false, errors.Wrapf(err, "failed to execute CmpAndSwap transaction on %s/%s and failed to rollback transaction", bucket, key)
+			return nil, false, errors.Wrap(err, "failed to execute CmpAndSwap transaction and failed to rollback transaction")
 		}
 		return nil, false, err
 	case swapped:
@@ -148,7 +197,7 @@ func (db *DB) CmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool,
 		return val, swapped, nil
 	default:
 		if err := boltTx.Rollback(); err != nil {
-			return nil, false, errors.Wrapf(err, "failed to rollback read-only CmpAndSwap transaction on %s/%s", bucket, key)
+			return nil, false, errors.Wrap(err, "failed to rollback read-only CmpAndSwap transaction")
 		}
 		return val, swapped, err
 	}
@@ -161,14 +210,14 @@ func cmpAndSwap(boltBucket *bolt.Bucket, key, oldValue, newValue []byte) ([]byte
 	}
 
 	if err := boltBucket.Put(key, newValue); err != nil {
-		return nil, false, errors.Wrapf(err, "failed to set key %s", key)
+		return nil, false, errors.Wrap(err, "failed to set key")
 	}
 	return newValue, true, nil
 }
 
 // Update performs multiple commands on one read-write transaction.
 func (db *DB) Update(tx *database.Tx) error {
-	return db.db.Update(func(boltTx *bolt.Tx) (err error) {
+	err := db.db.Update(func(boltTx *bolt.Tx) (err error) {
 		var b *bolt.Bucket
 		for _, q := range tx.Operations {
 			// create or delete buckets
@@ -176,13 +225,13 @@ func (db *DB) Update(tx *database.Tx) error {
 			case database.CreateTable:
 				err = db.createBucket(boltTx, q.Bucket)
 				if err != nil {
-					return err
+					return database.TxOpError(q, err)
 				}
 				continue
 			case database.DeleteTable:
 				err = db.deleteBucket(boltTx, q.Bucket)
 				if err != nil {
-					return err
+					return database.TxOpError(q, err)
 				}
 				continue
 			}
@@ -194,30 +243,40 @@ func (db *DB) Update(tx *database.Tx) error {
 			case database.Get:
 				ret := b.Get(q.Key)
 				if ret == nil {
-					return errors.WithStack(database.ErrNotFound)
+					return database.TxOpError(q, errors.WithStack(database.ErrNotFound))
 				}
 				q.Result = cloneBytes(ret)
 			case database.Set:
+				if err = db.valueValidator.Validate(q.Bucket, q.Key, q.Value); err != nil {
+					return database.TxOpError(q, err)
+				}
 				if err = b.Put(q.Key, q.Value); err != nil {
-					return errors.WithStack(err)
+					return database.TxOpError(q, errors.WithStack(err))
 				}
 			case database.Delete:
 				if err = b.Delete(q.Key); err != nil {
-					return errors.WithStack(err)
+					return database.TxOpError(q, errors.WithStack(err))
 				}
 			case database.CmpAndSwap:
+				if err = db.valueValidator.Validate(q.Bucket, q.Key, q.Value); err != nil {
+					return database.TxOpError(q, err)
+				}
 				q.Result, q.Swapped, err = cmpAndSwap(b, q.Key, q.CmpValue, q.Value)
 				if err != nil {
-					return errors.Wrapf(err, "failed to execute CmpAndSwap on %s/%s", q.Bucket, q.Key)
+					return database.TxOpError(q, err)
 				}
 			case database.CmpOrRollback:
-				return errors.Errorf("operation '%s' is not yet implemented", q.Cmd)
+				return database.TxOpError(q, errors.Errorf("operation '%s' is not yet implemented", q.Cmd))
 			default:
-				return errors.Errorf("operation '%s' is not supported", q.Cmd)
+				return database.TxOpError(q, errors.Errorf("operation '%s' is not supported", q.Cmd))
 			}
 		}
 		return nil
 	})
+	if err == nil {
+		db.notifier.NotifyTx(tx)
+	}
+	return err
 }
 
 // getBucket returns the bucket supporting nested buckets, nested buckets are
@@ -262,7 +321,7 @@ func (db *DB) deleteBucket(tx *bolt.Tx, name []byte) (err error) {
 	}
 	err = b.DeleteBucket(buckets[last])
 	if errors.Is(err, bolt.ErrBucketNotFound) {
-		return errors.Wrapf(database.ErrNotFound, "bucket %s does not exist", name)
+		return errors.Wrap(database.ErrNotFound, "bucket does not exist")
 	}
 	return
 }
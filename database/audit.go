@@ -0,0 +1,76 @@
+package database
+
+import (
+	"sync"
+	"time"
+)
+
+// AuditEvent describes a successful mutation of an entry in the database. For
+// privacy reasons, events do not include the value written.
+type AuditEvent struct {
+	Bucket []byte
+	Key    []byte
+	// Op is the operation performed, one of Set, Delete or CmpAndSwap.
+	Op   TxCmd
+	Time time.Time
+}
+
+// AuditSink is the interface used to record audit events. Record is called
+// synchronously after each successful mutation, implementations that might
+// block should be wrapped with NewBufferedAuditSink.
+type AuditSink interface {
+	Record(event AuditEvent)
+}
+
+// BufferedAuditSink is an AuditSink that delivers the events to another sink
+// in the background. Recording is best-effort, events are dropped if the
+// buffer is full.
+type BufferedAuditSink struct {
+	mu     sync.RWMutex
+	sink   AuditSink
+	events chan AuditEvent
+	done   chan struct{}
+	closed bool
+}
+
+// NewBufferedAuditSink returns a BufferedAuditSink that buffers up to size
+// events before delivering them to the given sink.
+func NewBufferedAuditSink(sink AuditSink, size int) *BufferedAuditSink {
+	s := &BufferedAuditSink{
+		sink:   sink,
+		events: make(chan AuditEvent, size),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		for e := range s.events {
+			s.sink.Record(e)
+		}
+	}()
+	return s
+}
+
+// Record queues the event without blocking.
+func (s *BufferedAuditSink) Record(event AuditEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.events <- event:
+	default:
+	}
+}
+
+// Close stops accepting events and waits until the queued ones are delivered.
+func (s *BufferedAuditSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+	s.mu.Unlock()
+	<-s.done
+	return nil
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/smallstep/assert"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []AuditEvent
+}
+
+func (s *recordingSink) Record(e AuditEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, e)
+}
+
+func TestBufferedAuditSink(t *testing.T) {
+	sink := &recordingSink{}
+	buffered := NewBufferedAuditSink(sink, 10)
+	for i := 0; i < 5; i++ {
+		buffered.Record(AuditEvent{Bucket: []byte("users"), Key: []byte("mike"), Op: Set})
+	}
+	assert.FatalError(t, buffered.Close())
+	assert.Len(t, 5, sink.events)
+
+	// Events recorded after Close are dropped.
+	buffered.Record(AuditEvent{Bucket: []byte("users"), Key: []byte("mike"), Op: Set})
+	assert.FatalError(t, buffered.Close())
+	assert.Len(t, 5, sink.events)
+}
@@ -0,0 +1,26 @@
+package database
+
+// CopyKey copies the value stored in the given bucket and srcKey to dstKey. If
+// overwrite is false, the value is written with CmpAndSwap and CopyKey fails
+// with ErrExists if dstKey already holds a value. Note that, as with
+// CmpAndSwap, an empty value is not distinguished from a missing one.
+func CopyKey(db DB, bucket, srcKey, dstKey []byte, overwrite bool) error {
+	value, err := db.Get(bucket, srcKey)
+	if err != nil {
+		return err
+	}
+
+	if overwrite {
+		return db.Set(bucket, dstKey, value)
+	}
+
+	_, swapped, err := db.CmpAndSwap(bucket, dstKey, nil, value)
+	switch {
+	case err != nil:
+		return err
+	case !swapped:
+		return &OpError{Op: "copy", Bucket: bucket, Key: dstKey, Err: ErrExists}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/smallstep/assert"
+)
+
+func TestCopyKey(t *testing.T) {
+	bucket := []byte("users")
+	db := newMemDB("users")
+	assert.FatalError(t, db.Set(bucket, []byte("mike"), []byte("malone")))
+	assert.FatalError(t, db.Set(bucket, []byte("max"), []byte("furman")))
+
+	// copy to a new key
+	assert.FatalError(t, CopyKey(db, bucket, []byte("mike"), []byte("mike2"), false))
+	v, err := db.Get(bucket, []byte("mike2"))
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte("malone"), v)
+
+	// refuse to overwrite an existing key
+	err = CopyKey(db, bucket, []byte("mike"), []byte("max"), false)
+	assert.True(t, IsErrExists(err))
+	assert.Equals(t, "copy users/max: already exists", err.Error())
+	v, err = db.Get(bucket, []byte("max"))
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte("furman"), v)
+
+	// overwrite an existing key
+	assert.FatalError(t, CopyKey(db, bucket, []byte("mike"), []byte("max"), true))
+	v, err = db.Get(bucket, []byte("max"))
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte("malone"), v)
+
+	// missing source
+	err = CopyKey(db, bucket, []byte("mariano"), []byte("mariano2"), true)
+	assert.True(t, IsErrNotFound(err))
+	_, err = db.Get(bucket, []byte("mariano2"))
+	assert.True(t, IsErrNotFound(err))
+}
@@ -13,6 +13,8 @@ var (
 	// ErrOpNotSupported is the type returned on DB implementations if an operation
 	// is not supported.
 	ErrOpNotSupported = errors.New("operation not supported")
+	// ErrExists is the type returned if an item already exists.
+	ErrExists = errors.New("already exists")
 )
 
 // IsErrNotFound returns true if the cause of the given error is ErrNotFound.
@@ -25,11 +27,70 @@ func IsErrOpNotSupported(err error) bool {
 	return errors.Is(err, ErrOpNotSupported)
 }
 
+// OpError is the error returned by the methods of the databases. It records
+// the operation and the bucket and key it failed on. Key is nil for the
+// operations on a whole bucket.
+type OpError struct {
+	Op     string
+	Bucket []byte
+	Key    []byte
+	Err    error
+}
+
+// Error implements the error interface on OpError.
+func (e *OpError) Error() string {
+	if e.Key == nil {
+		return fmt.Sprintf("%s %s: %v", e.Op, e.Bucket, e.Err)
+	}
+	return fmt.Sprintf("%s %s/%s: %v", e.Op, e.Bucket, e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// asOpError returns err as an *OpError for the given operation, bucket and
+// key. Errors that already are an *OpError, like the ones returned by the
+// databases, are returned unchanged.
+func asOpError(op string, bucket, key []byte, err error) error {
+	var opErr *OpError
+	if errors.As(err, &opErr) {
+		return err
+	}
+	return &OpError{Op: op, Bucket: bucket, Key: key, Err: err}
+}
+
+// TxOpError returns the OpError for the operation q of a transaction that
+// failed with err. The operation is named like the DB method running the same
+// command.
+func TxOpError(q *TxEntry, err error) error {
+	op := q.Cmd.String()
+	switch q.Cmd {
+	case Get:
+		op = "get"
+	case Set:
+		op = "set"
+	case CmpAndSwap:
+		op = "cmpandswap"
+	}
+	return &OpError{Op: op, Bucket: q.Bucket, Key: q.Key, Err: err}
+}
+
+// IsErrExists returns true if the cause of the given error is ErrExists.
+func IsErrExists(err error) bool {
+	return errors.Is(err, ErrExists)
+}
+
 // Options are configuration options for the database.
 type Options struct {
 	Database              string
 	ValueDir              string
 	BadgerFileLoadingMode string
+	ValueValidator        ValueValidator
+	AuditSink             AuditSink
+	ChangeHook            func(ChangeEvent)
+	MissingTableNotFound  bool
 }
 
 // Option is the modifier type over Options.
@@ -60,6 +121,61 @@ func WithBadgerFileLoadingMode(mode string) Option {
 	}
 }
 
+// WithValueValidator is a modifier that sets the ValueValidator attribute of
+// Options. The validator is called before every write, and a write is rejected
+// with the error returned by it.
+func WithValueValidator(fn ValueValidator) Option {
+	return func(o *Options) error {
+		o.ValueValidator = fn
+		return nil
+	}
+}
+
+// ValueValidator is the type of the functions used to validate values before
+// they are written to the database.
+type ValueValidator func(bucket, key, value []byte) error
+
+// Validate calls the validator with the given bucket, key and value. It always
+// succeeds if the validator is nil.
+func (fn ValueValidator) Validate(bucket, key, value []byte) error {
+	if fn == nil {
+		return nil
+	}
+	return fn(bucket, key, value)
+}
+
+// WithAuditSink is a modifier that sets the AuditSink attribute of Options.
+// The sink records an event after every successful Set, Del, CmpAndSwap and
+// Update.
+func WithAuditSink(sink AuditSink) Option {
+	return func(o *Options) error {
+		o.AuditSink = sink
+		return nil
+	}
+}
+
+// WithChangeHook is a modifier that sets the ChangeHook attribute of Options.
+// The hook is called synchronously after every successful Set, Del,
+// CmpAndSwap and Update made through the database, use AsyncChangeHook to
+// run it in the background. Changes made by other clients are not observed.
+func WithChangeHook(fn func(ChangeEvent)) Option {
+	return func(o *Options) error {
+		o.ChangeHook = fn
+		return nil
+	}
+}
+
+// WithMissingTableAsNotFound is a modifier that sets the MissingTableNotFound
+// attribute of Options. If enabled, databases that report a missing table as
+// a distinct error, like MySQL and PostgreSQL, return ErrNotFound on a Get
+// from a table that does not exist.
+func WithMissingTableAsNotFound(enabled bool) Option {
+	return func(o *Options) error {
+		o.MissingTableNotFound = enabled
+		return nil
+	}
+}
+
 // DB is a interface to be implemented by the databases.
 type DB interface {
 	// Open opens the database available with the given options.
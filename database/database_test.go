@@ -0,0 +1,54 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smallstep/assert"
+)
+
+func TestOpError(t *testing.T) {
+	var err error = &OpError{
+		Op:     "get",
+		Bucket: []byte("users"),
+		Key:    []byte("mike"),
+		Err:    ErrNotFound,
+	}
+	assert.Equals(t, "get users/mike: not found", err.Error())
+	assert.True(t, IsErrNotFound(err))
+	assert.False(t, IsErrOpNotSupported(err))
+
+	var opErr *OpError
+	if assert.True(t, errors.As(err, &opErr)) {
+		assert.Equals(t, "get", opErr.Op)
+		assert.Equals(t, []byte("users"), opErr.Bucket)
+		assert.Equals(t, []byte("mike"), opErr.Key)
+	}
+}
+
+func TestOpErrorBucket(t *testing.T) {
+	err := &OpError{Op: "list", Bucket: []byte("users"), Err: ErrNotFound}
+	assert.Equals(t, "list users: not found", err.Error())
+	assert.True(t, IsErrNotFound(err))
+}
+
+func TestTxOpError(t *testing.T) {
+	tests := []struct {
+		cmd  TxCmd
+		want string
+	}{
+		{CreateTable, "create-table users/mike: not found"},
+		{DeleteTable, "delete-table users/mike: not found"},
+		{Get, "get users/mike: not found"},
+		{Set, "set users/mike: not found"},
+		{Delete, "delete users/mike: not found"},
+		{CmpAndSwap, "cmpandswap users/mike: not found"},
+		{CmpOrRollback, "compare-and-rollback users/mike: not found"},
+	}
+	for _, tt := range tests {
+		q := &TxEntry{Bucket: []byte("users"), Key: []byte("mike"), Cmd: tt.cmd}
+		err := TxOpError(q, ErrNotFound)
+		assert.Equals(t, tt.want, err.Error())
+		assert.True(t, IsErrNotFound(err))
+	}
+}
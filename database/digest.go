@@ -0,0 +1,38 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// BucketDigest returns a SHA-256 digest of all the entries in the given
+// bucket. The digest does not depend on the order in which the entries are
+// listed, so it can be used to compare copies of a bucket stored in different
+// databases. It requires reading the full bucket.
+func BucketDigest(db DB, bucket []byte) ([]byte, error) {
+	entries, err := db.List(bucket)
+	if err != nil {
+		return nil, asOpError("list", bucket, nil, err)
+	}
+
+	// The digest is the hash of the number of entries and the XOR of the
+	// hashes of each entry.
+	var sum [sha256.Size]byte
+	var buf [binary.MaxVarintLen64]byte
+	for _, e := range entries {
+		h := sha256.New()
+		n := binary.PutUvarint(buf[:], uint64(len(e.Key)))
+		h.Write(buf[:n])
+		h.Write(e.Key)
+		h.Write(e.Value)
+		for i, b := range h.Sum(nil) {
+			sum[i] ^= b
+		}
+	}
+
+	h := sha256.New()
+	n := binary.PutUvarint(buf[:], uint64(len(entries)))
+	h.Write(buf[:n])
+	h.Write(sum[:])
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/smallstep/assert"
+)
+
+func TestBucketDigest(t *testing.T) {
+	bucket := []byte("users")
+	a, b := newMemDB("users"), newMemDB("users")
+	for _, kv := range [][2]string{{"mike", "malone"}, {"max", "furman"}, {"mariano", "cano"}} {
+		assert.FatalError(t, a.Set(bucket, []byte(kv[0]), []byte(kv[1])))
+	}
+	// Same entries, different insertion order.
+	for _, kv := range [][2]string{{"mariano", "cano"}, {"mike", "malone"}, {"max", "furman"}} {
+		assert.FatalError(t, b.Set(bucket, []byte(kv[0]), []byte(kv[1])))
+	}
+
+	digestA, err := BucketDigest(a, bucket)
+	assert.FatalError(t, err)
+	digestB, err := BucketDigest(b, bucket)
+	assert.FatalError(t, err)
+	assert.Len(t, 32, digestA)
+	assert.Equals(t, digestA, digestB)
+
+	// A single different value changes the digest.
+	assert.FatalError(t, b.Set(bucket, []byte("max"), []byte("maxey")))
+	digestB, err = BucketDigest(b, bucket)
+	assert.FatalError(t, err)
+	assert.NotEquals(t, digestA, digestB)
+
+	// Moving bytes between key and value changes the digest.
+	c := newMemDB("users")
+	assert.FatalError(t, c.Set(bucket, []byte("mikem"), []byte("alone")))
+	assert.FatalError(t, c.Set(bucket, []byte("max"), []byte("furman")))
+	assert.FatalError(t, c.Set(bucket, []byte("mariano"), []byte("cano")))
+	digestC, err := BucketDigest(c, bucket)
+	assert.FatalError(t, err)
+	assert.NotEquals(t, digestA, digestC)
+
+	_, err = BucketDigest(a, []byte("missing"))
+	assert.True(t, IsErrNotFound(err))
+	assert.Equals(t, "list missing: not found", err.Error())
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// snapshotMagic is the header that identifies snapshots created by
+// SnapshotToWriter. It's followed by a byte with the format version.
+var snapshotMagic = []byte("nosql-snapshot")
+
+// snapshotVersion is the current version of the snapshot format.
+const snapshotVersion = 1
+
+// ExportCSV writes all the entries in the given bucket to w as CSV records
+// with two fields, the key and the value, both base64 encoded.
+func ExportCSV(db DB, bucket []byte, w io.Writer) error {
+	entries, err := db.List(bucket)
+	if err != nil {
+		return asOpError("list", bucket, nil, err)
+	}
+
+	cw := csv.NewWriter(w)
+	for _, e := range entries {
+		if err := cw.Write([]string{
+			base64.StdEncoding.EncodeToString(e.Key),
+			base64.StdEncoding.EncodeToString(e.Value),
+		}); err != nil {
+			return fmt.Errorf("error writing CSV record: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("error writing CSV: %w", err)
+	}
+	return nil
+}
+
+// SnapshotToWriter writes all the entries in the given bucket to w as a gzip
+// compressed snapshot. The snapshot starts with a header and version, followed
+// by the length-prefixed keys and values of each entry. Use RestoreFromReader
+// to load it back.
+func SnapshotToWriter(db DB, bucket []byte, w io.Writer) error {
+	entries, err := db.List(bucket)
+	if err != nil {
+		return asOpError("list", bucket, nil, err)
+	}
+
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+	bw.Write(snapshotMagic)
+	bw.WriteByte(snapshotVersion)
+
+	var buf [binary.MaxVarintLen64]byte
+	for _, e := range entries {
+		for _, b := range [][]byte{e.Key, e.Value} {
+			n := binary.PutUvarint(buf[:], uint64(len(b)))
+			bw.Write(buf[:n])
+			bw.Write(b)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+	return nil
+}
+
+// RestoreFromReader loads a snapshot created by SnapshotToWriter into the
+// given bucket, creating it if necessary. Entries are written one by one, so a
+// failure might leave the bucket partially restored.
+func RestoreFromReader(db DB, bucket []byte, r io.Reader) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("error reading snapshot: %w", err)
+	}
+	defer zr.Close()
+	br := bufio.NewReader(zr)
+
+	header := make([]byte, len(snapshotMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return fmt.Errorf("error reading snapshot header: %w", err)
+	}
+	if !bytes.Equal(header[:len(snapshotMagic)], snapshotMagic) {
+		return errors.New("error reading snapshot: invalid header")
+	}
+	if v := header[len(snapshotMagic)]; v != snapshotVersion {
+		return fmt.Errorf("error reading snapshot: unsupported version %d", v)
+	}
+
+	if err := db.CreateTable(bucket); err != nil {
+		return asOpError("create-table", bucket, nil, err)
+	}
+	for {
+		key, err := readSnapshotField(br)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		value, err := readSnapshotField(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if err := db.Set(bucket, key, value); err != nil {
+			return asOpError("set", bucket, key, err)
+		}
+	}
+}
+
+// readSnapshotField reads a length-prefixed field from a snapshot. It returns
+// io.EOF only if there are no more fields.
+func readSnapshotField(br *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error reading snapshot: %w", err)
+	}
+	if n > math.MaxInt64 {
+		return nil, fmt.Errorf("error reading snapshot: invalid field length %d", n)
+	}
+	// The length is not trusted, so the buffer only grows with the data read.
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, br, int64(n)); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("error reading snapshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
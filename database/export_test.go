@@ -0,0 +1,114 @@
+package database
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/smallstep/assert"
+)
+
+func TestExportCSV(t *testing.T) {
+	bucket := []byte("users")
+	db := newMemDB("users")
+	assert.FatalError(t, db.Set(bucket, []byte("mike"), []byte("malone")))
+	assert.FatalError(t, db.Set(bucket, []byte("max"), []byte("furman,\n\"max\"")))
+	assert.FatalError(t, db.Set(bucket, []byte("bin"), []byte{0, 1, 2, 255}))
+
+	var buf bytes.Buffer
+	assert.FatalError(t, ExportCSV(db, bucket, &buf))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.FatalError(t, err)
+	assert.Len(t, 3, records)
+
+	got := make(map[string][]byte)
+	for _, r := range records {
+		assert.Len(t, 2, r)
+		k, err := base64.StdEncoding.DecodeString(r[0])
+		assert.FatalError(t, err)
+		v, err := base64.StdEncoding.DecodeString(r[1])
+		assert.FatalError(t, err)
+		got[string(k)] = v
+	}
+	assert.Equals(t, []byte("malone"), got["mike"])
+	assert.Equals(t, []byte("furman,\n\"max\""), got["max"])
+	assert.Equals(t, []byte{0, 1, 2, 255}, got["bin"])
+
+	err = ExportCSV(db, []byte("missing"), &buf)
+	assert.True(t, IsErrNotFound(err))
+	assert.Equals(t, "list missing: not found", err.Error())
+	err = ExportCSV(&listFailingDB{DB: db}, bucket, &buf)
+	assert.Equals(t, "list users: not found", err.Error())
+}
+
+func TestSnapshot(t *testing.T) {
+	bucket := []byte("users")
+	db := newMemDB("users")
+	assert.FatalError(t, db.Set(bucket, []byte("mike"), []byte("malone")))
+	assert.FatalError(t, db.Set(bucket, []byte("max"), []byte("furman")))
+	assert.FatalError(t, db.Set(bucket, []byte("empty"), []byte{}))
+	assert.FatalError(t, db.Set(bucket, []byte("large"), bytes.Repeat([]byte{0, 1, 2}, 10000)))
+
+	var buf bytes.Buffer
+	assert.FatalError(t, SnapshotToWriter(db, bucket, &buf))
+	snapshot := buf.Bytes()
+
+	restored := newMemDB()
+	assert.FatalError(t, RestoreFromReader(restored, []byte("users-copy"), bytes.NewReader(snapshot)))
+
+	want, err := db.List(bucket)
+	assert.FatalError(t, err)
+	got, err := restored.List([]byte("users-copy"))
+	assert.FatalError(t, err)
+	if assert.Len(t, len(want), got) {
+		for i := range want {
+			assert.Equals(t, want[i].Key, got[i].Key)
+			assert.Equals(t, want[i].Value, got[i].Value)
+		}
+	}
+
+	// Truncated snapshot.
+	err = RestoreFromReader(newMemDB(), bucket, bytes.NewReader(snapshot[:len(snapshot)/2]))
+	assert.Error(t, err)
+
+	// Oversized field length.
+	var huge bytes.Buffer
+	zw := gzip.NewWriter(&huge)
+	zw.Write(append(append([]byte{}, snapshotMagic...), snapshotVersion))
+	zw.Write([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+	assert.FatalError(t, zw.Close())
+	err = RestoreFromReader(newMemDB(), bucket, &huge)
+	assert.Equals(t, "error reading snapshot: invalid field length 18446744073709551615", err.Error())
+
+	huge.Reset()
+	zw = gzip.NewWriter(&huge)
+	zw.Write(append(append([]byte{}, snapshotMagic...), snapshotVersion))
+	zw.Write([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f})
+	assert.FatalError(t, zw.Close())
+	err = RestoreFromReader(newMemDB(), bucket, &huge)
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+
+	// Unknown version.
+	var bad bytes.Buffer
+	zw = gzip.NewWriter(&bad)
+	zw.Write(append(append([]byte{}, snapshotMagic...), snapshotVersion+1))
+	assert.FatalError(t, zw.Close())
+	err = RestoreFromReader(newMemDB(), bucket, &bad)
+	assert.Equals(t, "error reading snapshot: unsupported version 2", err.Error())
+
+	// Failing write.
+	err = RestoreFromReader(&setFailingDB{DB: newMemDB(), err: errors.New("force")}, bucket, bytes.NewReader(snapshot))
+	assert.Equals(t, "set users/empty: force", err.Error())
+
+	// Missing bucket.
+	err = SnapshotToWriter(db, []byte("missing"), &buf)
+	assert.True(t, IsErrNotFound(err))
+	assert.Equals(t, "list missing: not found", err.Error())
+	err = SnapshotToWriter(&listFailingDB{DB: db}, bucket, &buf)
+	assert.Equals(t, "list users: not found", err.Error())
+}
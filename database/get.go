@@ -0,0 +1,113 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// GetOrDefault returns the value stored in the given bucket and key, or def if
+// the key does not exist. A key holding an empty value is not considered
+// missing. Errors other than ErrNotFound are returned to the caller.
+func GetOrDefault(db DB, bucket, key, def []byte) ([]byte, error) {
+	v, err := db.Get(bucket, key)
+	switch {
+	case IsErrNotFound(err):
+		return def, nil
+	case err != nil:
+		return nil, err
+	default:
+		return v, nil
+	}
+}
+
+// BatchGetPartial returns the values stored in the given bucket for the keys
+// that exist, indexed by key, and the keys that do not exist, in the order
+// given. Keys are read one by one; errors other than ErrNotFound are returned
+// to the caller.
+func BatchGetPartial(db DB, bucket []byte, keys [][]byte) (found map[string][]byte, missing [][]byte, err error) {
+	found = make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		v, err := db.Get(bucket, key)
+		switch {
+		case IsErrNotFound(err):
+			missing = append(missing, key)
+		case err != nil:
+			return nil, nil, err
+		default:
+			found[string(key)] = v
+		}
+	}
+	return found, missing, nil
+}
+
+// Loader populates missing keys on read. A Loader must not be copied after
+// first use; the zero value is ready to use.
+type Loader struct {
+	mu    sync.Mutex
+	calls map[string]*loadCall
+}
+
+// errLoadPanicked is returned to the callers waiting for a load that panicked.
+var errLoadPanicked = errors.New("load panicked")
+
+type loadCall struct {
+	wg  sync.WaitGroup
+	val []byte
+	err error
+}
+
+// GetOrLoad returns the value stored in the given bucket and key. If the key
+// does not exist, it calls load, stores the value returned by it and returns
+// it. Concurrent calls for the same bucket and key share a single call to
+// load. The value is stored with CmpAndSwap, so if another client stores a
+// value first, that value is returned instead. If load panics, the panic is
+// propagated to the caller that ran it and the others get an error.
+func (l *Loader) GetOrLoad(db DB, bucket, key []byte, load func() ([]byte, error)) ([]byte, error) {
+	v, err := db.Get(bucket, key)
+	if !IsErrNotFound(err) {
+		return v, err
+	}
+
+	id := fmt.Sprintf("%x/%x", bucket, key)
+	l.mu.Lock()
+	if c, ok := l.calls[id]; ok {
+		l.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err
+	}
+	if l.calls == nil {
+		l.calls = make(map[string]*loadCall)
+	}
+	c := new(loadCall)
+	c.wg.Add(1)
+	l.calls[id] = c
+	l.mu.Unlock()
+
+	// Release the waiting callers even if load panics.
+	defer func() {
+		l.mu.Lock()
+		delete(l.calls, id)
+		l.mu.Unlock()
+		c.wg.Done()
+	}()
+	c.err = errLoadPanicked
+	c.val, c.err = loadAndStore(db, bucket, key, load)
+	return c.val, c.err
+}
+
+func loadAndStore(db DB, bucket, key []byte, load func() ([]byte, error)) ([]byte, error) {
+	v, err := load()
+	if err != nil {
+		return nil, err
+	}
+	current, swapped, err := db.CmpAndSwap(bucket, key, nil, v)
+	switch {
+	case err != nil:
+		return nil, err
+	case swapped:
+		return v, nil
+	default:
+		return current, nil
+	}
+}
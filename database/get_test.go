@@ -0,0 +1,181 @@
+package database
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smallstep/assert"
+)
+
+func TestGetOrDefault(t *testing.T) {
+	bucket := []byte("users")
+	db := newMemDB("users")
+	assert.FatalError(t, db.Set(bucket, []byte("mike"), []byte("malone")))
+	assert.FatalError(t, db.Set(bucket, []byte("empty"), []byte{}))
+
+	tests := []struct {
+		name string
+		db   DB
+		key  []byte
+		want []byte
+		err  error
+	}{
+		{"ok/present", db, []byte("mike"), []byte("malone"), nil},
+		{"ok/empty", db, []byte("empty"), []byte{}, nil},
+		{"ok/absent", db, []byte("max"), []byte("default"), nil},
+		{"fail/error", &failingDB{DB: db, err: errors.New("force")}, []byte("mike"), nil, errors.New("force")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetOrDefault(tt.db, bucket, tt.key, []byte("default"))
+			if tt.err != nil {
+				assert.Equals(t, tt.err, err)
+				assert.Nil(t, got)
+			} else {
+				assert.FatalError(t, err)
+				assert.Equals(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBatchGetPartial(t *testing.T) {
+	bucket := []byte("users")
+	db := newMemDB("users")
+	assert.FatalError(t, db.Set(bucket, []byte("mike"), []byte("malone")))
+	assert.FatalError(t, db.Set(bucket, []byte("max"), []byte("furman")))
+	assert.FatalError(t, db.Set(bucket, []byte("empty"), []byte{}))
+
+	tests := []struct {
+		name        string
+		db          DB
+		keys        [][]byte
+		wantFound   map[string][]byte
+		wantMissing [][]byte
+		err         error
+	}{
+		{"ok/found", db, [][]byte{[]byte("mike"), []byte("max")}, map[string][]byte{
+			"mike": []byte("malone"), "max": []byte("furman"),
+		}, nil, nil},
+		{"ok/missing", db, [][]byte{[]byte("seb"), []byte("mariano")}, map[string][]byte{},
+			[][]byte{[]byte("seb"), []byte("mariano")}, nil},
+		{"ok/mixed", db, [][]byte{[]byte("seb"), []byte("mike"), []byte("empty"), []byte("mariano")}, map[string][]byte{
+			"mike": []byte("malone"), "empty": {},
+		}, [][]byte{[]byte("seb"), []byte("mariano")}, nil},
+		{"ok/none", db, nil, map[string][]byte{}, nil, nil},
+		{"fail/error", &failingDB{DB: db, err: errors.New("force")}, [][]byte{[]byte("mike")}, nil, nil, errors.New("force")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, missing, err := BatchGetPartial(tt.db, bucket, tt.keys)
+			if tt.err != nil {
+				assert.Equals(t, tt.err, err)
+				assert.Nil(t, found)
+				assert.Nil(t, missing)
+			} else {
+				assert.FatalError(t, err)
+				assert.Equals(t, tt.wantFound, found)
+				assert.Equals(t, tt.wantMissing, missing)
+			}
+		})
+	}
+}
+
+func TestLoader_GetOrLoad(t *testing.T) {
+	bucket := []byte("users")
+	db := newMemDB("users")
+	assert.FatalError(t, db.Set(bucket, []byte("mike"), []byte("malone")))
+
+	var l Loader
+	fail := func() ([]byte, error) {
+		t.Error("unexpected call to load")
+		return nil, errors.New("force")
+	}
+
+	// present
+	v, err := l.GetOrLoad(db, bucket, []byte("mike"), fail)
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte("malone"), v)
+
+	// error
+	_, err = l.GetOrLoad(&failingDB{DB: db, err: errors.New("force")}, bucket, []byte("max"), fail)
+	assert.Equals(t, errors.New("force"), err)
+
+	// load error
+	_, err = l.GetOrLoad(db, bucket, []byte("max"), func() ([]byte, error) {
+		return nil, errors.New("load failed")
+	})
+	assert.Equals(t, errors.New("load failed"), err)
+	_, err = db.Get(bucket, []byte("max"))
+	assert.True(t, IsErrNotFound(err))
+
+	// concurrent misses
+	const n = 10
+	var (
+		calls   int
+		started sync.WaitGroup
+		done    sync.WaitGroup
+		mu      sync.Mutex
+	)
+	load := func() ([]byte, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		// Give the other callers time to wait for this call.
+		started.Wait()
+		time.Sleep(50 * time.Millisecond)
+		return []byte("furman"), nil
+	}
+	results := make([][]byte, n)
+	started.Add(n)
+	done.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer done.Done()
+			started.Done()
+			v, err := l.GetOrLoad(db, bucket, []byte("max"), load)
+			assert.NoError(t, err)
+			results[i] = v
+		}(i)
+	}
+	done.Wait()
+	assert.Equals(t, 1, calls)
+	for _, v := range results {
+		assert.Equals(t, []byte("furman"), v)
+	}
+	v, err = db.Get(bucket, []byte("max"))
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte("furman"), v)
+
+	// load panics
+	entered := make(chan struct{})
+	panicked := make(chan interface{})
+	go func() {
+		defer func() { panicked <- recover() }()
+		l.GetOrLoad(db, bucket, []byte("seb"), func() ([]byte, error) {
+			close(entered)
+			// Give the other caller time to wait for this call.
+			time.Sleep(50 * time.Millisecond)
+			panic("boom")
+		})
+	}()
+	<-entered
+	_, err = l.GetOrLoad(db, bucket, []byte("seb"), fail)
+	assert.Equals(t, errLoadPanicked, err)
+	assert.Equals(t, "boom", <-panicked)
+	v, err = l.GetOrLoad(db, bucket, []byte("seb"), func() ([]byte, error) {
+		return []byte("tiedtke"), nil
+	})
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte("tiedtke"), v)
+
+	// value stored by another client while loading
+	v, err = l.GetOrLoad(db, bucket, []byte("mariano"), func() ([]byte, error) {
+		assert.FatalError(t, db.Set(bucket, []byte("mariano"), []byte("cano")))
+		return []byte("other"), nil
+	})
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte("cano"), v)
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"sync"
+)
+
+// ListMulti lists all the entries in the given buckets and returns them
+// grouped by bucket name. Up to concurrency buckets are listed at the same
+// time; a value lower than 2 lists them sequentially. If listing a bucket
+// fails, the first error is returned as an *OpError with the bucket.
+func ListMulti(db DB, buckets [][]byte, concurrency int) (map[string][]*Entry, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		sem      = make(chan struct{}, concurrency)
+		result   = make(map[string][]*Entry, len(buckets))
+	)
+	for _, bucket := range buckets {
+		sem <- struct{}{}
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(bucket []byte) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			entries, err := db.List(bucket)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = asOpError("list", bucket, nil, err)
+				}
+				return
+			}
+			result[string(bucket)] = entries
+		}(bucket)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return result, nil
+}
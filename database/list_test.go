@@ -0,0 +1,71 @@
+package database
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/smallstep/assert"
+)
+
+func TestListMulti(t *testing.T) {
+	db := newMemDB("users", "admins", "tokens")
+	assert.FatalError(t, db.Set([]byte("users"), []byte("mike"), []byte("1")))
+	assert.FatalError(t, db.Set([]byte("users"), []byte("max"), []byte("2")))
+	assert.FatalError(t, db.Set([]byte("admins"), []byte("mariano"), []byte("3")))
+
+	buckets := [][]byte{[]byte("users"), []byte("admins"), []byte("tokens")}
+	for _, concurrency := range []int{0, 1, 3} {
+		got, err := ListMulti(db, buckets, concurrency)
+		assert.FatalError(t, err)
+		assert.Len(t, 3, got)
+		assert.Len(t, 2, got["users"])
+		assert.Equals(t, []byte("max"), got["users"][0].Key)
+		assert.Equals(t, []byte("mike"), got["users"][1].Key)
+		assert.Len(t, 1, got["admins"])
+		assert.Equals(t, []byte("3"), got["admins"][0].Value)
+		assert.Len(t, 0, got["tokens"])
+	}
+
+	_, err := ListMulti(db, [][]byte{[]byte("users"), []byte("missing")}, 2)
+	assert.True(t, IsErrNotFound(err))
+	var opErr *OpError
+	if assert.True(t, errors.As(err, &opErr)) {
+		assert.Equals(t, "list", opErr.Op)
+		assert.Equals(t, []byte("missing"), opErr.Bucket)
+	}
+
+	// Errors from the database are not wrapped again.
+	_, err = ListMulti(&listFailingDB{DB: db}, [][]byte{[]byte("users")}, 1)
+	assert.Equals(t, "list users: not found", err.Error())
+
+	// Sequential listing stops at the failing bucket.
+	counting := &countingDB{DB: db}
+	_, err = ListMulti(counting, [][]byte{[]byte("users"), []byte("missing"), []byte("admins"), []byte("tokens")}, 1)
+	assert.True(t, IsErrNotFound(err))
+	assert.Equals(t, []string{"users", "missing"}, counting.listed)
+}
+
+// countingDB is a DB that records the buckets listed.
+type countingDB struct {
+	DB
+	mu     sync.Mutex
+	listed []string
+}
+
+func (db *countingDB) List(bucket []byte) ([]*Entry, error) {
+	db.mu.Lock()
+	db.listed = append(db.listed, string(bucket))
+	db.mu.Unlock()
+	return db.DB.List(bucket)
+}
+
+// listFailingDB is a DB whose List always fails with an *OpError, like the
+// databases do.
+type listFailingDB struct {
+	DB
+}
+
+func (db *listFailingDB) List(bucket []byte) ([]*Entry, error) {
+	return nil, &OpError{Op: "list", Bucket: bucket, Err: ErrNotFound}
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+// memDB is a minimal in-memory DB used to test the helpers in this package.
+type memDB struct {
+	mu      sync.Mutex
+	buckets map[string]map[string][]byte
+}
+
+func newMemDB(buckets ...string) *memDB {
+	db := &memDB{buckets: make(map[string]map[string][]byte)}
+	for _, b := range buckets {
+		db.buckets[b] = make(map[string][]byte)
+	}
+	return db
+}
+
+func (db *memDB) Open(dataSourceName string, opt ...Option) error { return nil }
+
+func (db *memDB) Close() error { return nil }
+
+func (db *memDB) Get(bucket, key []byte) ([]byte, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.get(bucket, key)
+}
+
+func (db *memDB) get(bucket, key []byte) ([]byte, error) {
+	b, ok := db.buckets[string(bucket)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	v, ok := b[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte{}, v...), nil
+}
+
+func (db *memDB) Set(bucket, key, value []byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.set(bucket, key, value)
+}
+
+func (db *memDB) set(bucket, key, value []byte) error {
+	b, ok := db.buckets[string(bucket)]
+	if !ok {
+		return ErrNotFound
+	}
+	b[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (db *memDB) CmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.cmpAndSwap(bucket, key, oldValue, newValue)
+}
+
+func (db *memDB) cmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool, error) {
+	b, ok := db.buckets[string(bucket)]
+	if !ok {
+		return nil, false, ErrNotFound
+	}
+	current := b[string(key)]
+	if !bytes.Equal(current, oldValue) {
+		return current, false, nil
+	}
+	b[string(key)] = append([]byte{}, newValue...)
+	return newValue, true, nil
+}
+
+func (db *memDB) Del(bucket, key []byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.del(bucket, key)
+}
+
+func (db *memDB) del(bucket, key []byte) error {
+	b, ok := db.buckets[string(bucket)]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(b, string(key))
+	return nil
+}
+
+func (db *memDB) List(bucket []byte) ([]*Entry, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	b, ok := db.buckets[string(bucket)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var entries []*Entry
+	for k, v := range b {
+		entries = append(entries, &Entry{
+			Bucket: bucket,
+			Key:    []byte(k),
+			Value:  append([]byte{}, v...),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Key, entries[j].Key) < 0
+	})
+	return entries, nil
+}
+
+func (db *memDB) Update(tx *Tx) (err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, q := range tx.Operations {
+		switch q.Cmd {
+		case CreateTable:
+			if _, ok := db.buckets[string(q.Bucket)]; !ok {
+				db.buckets[string(q.Bucket)] = make(map[string][]byte)
+			}
+		case DeleteTable:
+			delete(db.buckets, string(q.Bucket))
+		case Get:
+			q.Result, err = db.get(q.Bucket, q.Key)
+		case Set:
+			err = db.set(q.Bucket, q.Key, q.Value)
+		case Delete:
+			err = db.del(q.Bucket, q.Key)
+		case CmpAndSwap:
+			q.Result, q.Swapped, err = db.cmpAndSwap(q.Bucket, q.Key, q.CmpValue, q.Value)
+		default:
+			err = ErrOpNotSupported
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *memDB) CreateTable(bucket []byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.buckets[string(bucket)]; !ok {
+		db.buckets[string(bucket)] = make(map[string][]byte)
+	}
+	return nil
+}
+
+func (db *memDB) DeleteTable(bucket []byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.buckets[string(bucket)]; !ok {
+		return ErrNotFound
+	}
+	delete(db.buckets, string(bucket))
+	return nil
+}
+
+// failingDB is a DB whose Get always fails with the given error.
+type failingDB struct {
+	DB
+	err error
+}
+
+func (db *failingDB) Get(bucket, key []byte) ([]byte, error) {
+	return nil, db.err
+}
+
+// setFailingDB is a DB whose Set always fails with the given error.
+type setFailingDB struct {
+	DB
+	err error
+}
+
+func (db *setFailingDB) Set(bucket, key, value []byte) error {
+	return db.err
+}
@@ -0,0 +1,82 @@
+package database
+
+import "time"
+
+// ChangeEvent describes a successful mutation of an entry in the database. It
+// is passed to the change hook configured with WithChangeHook.
+type ChangeEvent struct {
+	// Op is the operation performed, one of Set, Delete or CmpAndSwap.
+	Op     TxCmd
+	Bucket []byte
+	Key    []byte
+	// Value is the new value for Set and CmpAndSwap operations.
+	Value []byte
+}
+
+// AsyncChangeHook returns a change hook that runs fn in a new goroutine, so
+// the database operations do not wait for it.
+func AsyncChangeHook(fn func(ChangeEvent)) func(ChangeEvent) {
+	return func(e ChangeEvent) {
+		go fn(e)
+	}
+}
+
+// Notifier reports the mutations performed by a database to the AuditSink and
+// change hook set in the Options. Databases create it on Open and call it
+// after each successful mutation. A nil Notifier does nothing.
+type Notifier struct {
+	auditSink  AuditSink
+	changeHook func(ChangeEvent)
+}
+
+// NewNotifier returns the Notifier for the given options, or nil if there
+// is nothing to notify.
+func NewNotifier(o *Options) *Notifier {
+	if o.AuditSink == nil && o.ChangeHook == nil {
+		return nil
+	}
+	return &Notifier{
+		auditSink:  o.AuditSink,
+		changeHook: o.ChangeHook,
+	}
+}
+
+// Notify reports a mutation on the given bucket and key. The value is only
+// reported to the change hook.
+func (n *Notifier) Notify(op TxCmd, bucket, key, value []byte) {
+	if n == nil {
+		return
+	}
+	if n.auditSink != nil {
+		n.auditSink.Record(AuditEvent{
+			Bucket: bucket,
+			Key:    key,
+			Op:     op,
+			Time:   time.Now(),
+		})
+	}
+	if n.changeHook != nil {
+		n.changeHook(ChangeEvent{
+			Op:     op,
+			Bucket: bucket,
+			Key:    key,
+			Value:  value,
+		})
+	}
+}
+
+// NotifyTx reports each mutation performed by a committed transaction.
+// Compare and swap operations are only reported if the value was swapped.
+func (n *Notifier) NotifyTx(tx *Tx) {
+	if n == nil {
+		return
+	}
+	for _, q := range tx.Operations {
+		switch {
+		case q.Cmd == Set, q.Cmd == CmpAndSwap && q.Swapped:
+			n.Notify(q.Cmd, q.Bucket, q.Key, q.Value)
+		case q.Cmd == Delete:
+			n.Notify(q.Cmd, q.Bucket, q.Key, nil)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/smallstep/assert"
+)
+
+func TestNotifier_NotifyTx(t *testing.T) {
+	bucket := []byte("users")
+	tx := &Tx{Operations: []*TxEntry{
+		{Bucket: bucket, Key: []byte("mike"), Cmd: Get},
+		{Bucket: bucket, Key: []byte("mike"), Value: []byte("malone"), Cmd: Set},
+		{Bucket: bucket, Key: []byte("max"), Cmd: Delete},
+		{Bucket: bucket, Key: []byte("mariano"), Value: []byte("cano"), Cmd: CmpAndSwap, Swapped: true},
+		{Bucket: bucket, Key: []byte("seb"), Value: []byte("tiedtke"), Cmd: CmpAndSwap, Swapped: false},
+	}}
+
+	// A nil notifier is a no-op.
+	assert.Nil(t, NewNotifier(&Options{}))
+	NewNotifier(&Options{}).NotifyTx(tx)
+
+	sink := &recordingSink{}
+	var changes []ChangeEvent
+	n := NewNotifier(&Options{
+		AuditSink:  sink,
+		ChangeHook: func(e ChangeEvent) { changes = append(changes, e) },
+	})
+	n.NotifyTx(tx)
+
+	assert.Equals(t, []ChangeEvent{
+		{Op: Set, Bucket: bucket, Key: []byte("mike"), Value: []byte("malone")},
+		{Op: Delete, Bucket: bucket, Key: []byte("max")},
+		{Op: CmpAndSwap, Bucket: bucket, Key: []byte("mariano"), Value: []byte("cano")},
+	}, changes)
+
+	if assert.Len(t, 3, sink.events) {
+		for i, e := range sink.events {
+			assert.Equals(t, changes[i].Op, e.Op)
+			assert.Equals(t, changes[i].Bucket, e.Bucket)
+			assert.Equals(t, changes[i].Key, e.Key)
+			assert.False(t, e.Time.IsZero())
+		}
+	}
+}
+
+func TestAsyncChangeHook(t *testing.T) {
+	var wg sync.WaitGroup
+	release := make(chan struct{})
+	events := make(chan ChangeEvent, 1)
+	hook := AsyncChangeHook(func(e ChangeEvent) {
+		defer wg.Done()
+		<-release
+		events <- e
+	})
+
+	// The hook returns before fn completes.
+	wg.Add(1)
+	hook(ChangeEvent{Op: Set, Bucket: []byte("users"), Key: []byte("mike")})
+	close(release)
+	wg.Wait()
+	e := <-events
+	assert.Equals(t, []byte("mike"), e.Key)
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// RepairKeys moves the entries in the given bucket whose keys need to be fixed
+// to their corrected keys. For each key in the bucket, detect returns the
+// corrected key and true if the key needs to be fixed. Each entry is moved
+// using a transaction that writes the value under the corrected key and
+// deletes the old one. RepairKeys never overwrites an existing entry; it
+// returns an error if a corrected key is already in use.
+//
+// RepairKeys returns the number of repaired keys, even if it fails.
+func RepairKeys(db DB, bucket []byte, detect func(stored []byte) (fixed []byte, ok bool)) (int, error) {
+	entries, err := db.List(bucket)
+	if err != nil {
+		return 0, asOpError("list", bucket, nil, err)
+	}
+
+	var repaired int
+	for _, e := range entries {
+		fixed, ok := detect(e.Key)
+		if !ok || bytes.Equal(fixed, e.Key) {
+			continue
+		}
+
+		switch _, err := db.Get(bucket, fixed); {
+		case err == nil:
+			return repaired, fmt.Errorf("error repairing %s/%s: key %s already exists", bucket, e.Key, fixed)
+		case !IsErrNotFound(err):
+			return repaired, fmt.Errorf("error repairing %s/%s: %w", bucket, e.Key, err)
+		}
+
+		tx := new(Tx)
+		tx.Set(bucket, fixed, e.Value)
+		tx.Del(bucket, e.Key)
+		if err := db.Update(tx); err != nil {
+			return repaired, fmt.Errorf("error repairing %s/%s: %w", bucket, e.Key, err)
+		}
+		repaired++
+	}
+	return repaired, nil
+}
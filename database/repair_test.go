@@ -0,0 +1,54 @@
+package database
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/smallstep/assert"
+)
+
+// decodeHexKey fixes keys that were stored hex encoded.
+func decodeHexKey(stored []byte) ([]byte, bool) {
+	fixed, err := hex.DecodeString(string(stored))
+	if err != nil {
+		return nil, false
+	}
+	return fixed, true
+}
+
+func TestRepairKeys(t *testing.T) {
+	bucket := []byte("users")
+	db := newMemDB("users")
+	assert.FatalError(t, db.Set(bucket, []byte(hex.EncodeToString([]byte("mike"))), []byte("malone")))
+	assert.FatalError(t, db.Set(bucket, []byte(hex.EncodeToString([]byte("max"))), []byte("furman")))
+	assert.FatalError(t, db.Set(bucket, []byte("mariano"), []byte("cano")))
+
+	n, err := RepairKeys(db, bucket, decodeHexKey)
+	assert.FatalError(t, err)
+	assert.Equals(t, 2, n)
+
+	entries, err := db.List(bucket)
+	assert.FatalError(t, err)
+	assert.Len(t, 3, entries)
+	assert.Equals(t, &Entry{Bucket: bucket, Key: []byte("mariano"), Value: []byte("cano")}, entries[0])
+	assert.Equals(t, &Entry{Bucket: bucket, Key: []byte("max"), Value: []byte("furman")}, entries[1])
+	assert.Equals(t, &Entry{Bucket: bucket, Key: []byte("mike"), Value: []byte("malone")}, entries[2])
+
+	// Repairing again is a no-op.
+	n, err = RepairKeys(db, bucket, decodeHexKey)
+	assert.FatalError(t, err)
+	assert.Equals(t, 0, n)
+
+	// Existing keys are not overwritten.
+	assert.FatalError(t, db.Set(bucket, []byte(hex.EncodeToString([]byte("mike"))), []byte("maxey")))
+	n, err = RepairKeys(db, bucket, decodeHexKey)
+	assert.Error(t, err)
+	assert.Equals(t, 0, n)
+	v, err := db.Get(bucket, []byte("mike"))
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte("malone"), v)
+
+	_, err = RepairKeys(db, []byte("missing"), decodeHexKey)
+	assert.True(t, IsErrNotFound(err))
+	assert.Equals(t, "list missing: not found", err.Error())
+}
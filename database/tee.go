@@ -0,0 +1,183 @@
+package database
+
+import (
+	"bytes"
+	"log"
+)
+
+// TeeMode configures the behavior of a TeeDB.
+type TeeMode int
+
+const (
+	// TeeCompareReads makes Get also read from the secondary database and report
+	// any divergence with the primary one.
+	TeeCompareReads TeeMode = 1 << iota
+	// TeeStrictWrites makes writes fail if they fail on the secondary database.
+	// By default those failures are only reported.
+	TeeStrictWrites
+)
+
+// TeeDB is a DB that writes to two databases and reads from the first one. It
+// can be used to migrate to a new database while still serving from the old
+// one. TeeDB never reads from the secondary database except to compare.
+type TeeDB struct {
+	primary   DB
+	secondary DB
+	mode      TeeMode
+
+	// OnSecondaryError is called when an operation fails on the secondary
+	// database. If it's nil the error is logged.
+	OnSecondaryError func(op string, bucket, key []byte, err error)
+	// OnDivergence is called in TeeCompareReads mode when the primary and
+	// secondary databases return different values for the same key. A nil
+	// value means that the key does not exist. If it's nil the divergence is
+	// logged.
+	OnDivergence func(bucket, key, primary, secondary []byte)
+}
+
+// NewTeeDB returns a TeeDB that writes to primary and secondary, and reads
+// from primary. Both databases must be already open.
+func NewTeeDB(primary, secondary DB, mode TeeMode) *TeeDB {
+	return &TeeDB{
+		primary:   primary,
+		secondary: secondary,
+		mode:      mode,
+	}
+}
+
+// Open is not supported, both databases must be opened before creating the
+// TeeDB.
+func (db *TeeDB) Open(dataSourceName string, opt ...Option) error {
+	return ErrOpNotSupported
+}
+
+// Close closes both databases.
+func (db *TeeDB) Close() error {
+	err := db.primary.Close()
+	if serr := db.secondary.Close(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// Get returns the value stored in the primary database. In TeeCompareReads
+// mode, it also reads the secondary database and reports if the values differ.
+func (db *TeeDB) Get(bucket, key []byte) ([]byte, error) {
+	v, err := db.primary.Get(bucket, key)
+	if db.mode&TeeCompareReads == 0 || (err != nil && !IsErrNotFound(err)) {
+		return v, err
+	}
+
+	sv, serr := db.secondary.Get(bucket, key)
+	if serr != nil && !IsErrNotFound(serr) {
+		db.secondaryError("get", bucket, key, serr)
+		return v, err
+	}
+	if IsErrNotFound(err) != IsErrNotFound(serr) || !bytes.Equal(v, sv) {
+		db.divergence(bucket, key, v, sv)
+	}
+	return v, err
+}
+
+// Set sets the value in both databases.
+func (db *TeeDB) Set(bucket, key, value []byte) error {
+	if err := db.primary.Set(bucket, key, value); err != nil {
+		return err
+	}
+	return db.secondaryWrite("set", bucket, key, db.secondary.Set(bucket, key, value))
+}
+
+// CmpAndSwap runs the compare and swap on the primary database. If the value
+// is swapped, the new value is set in the secondary database.
+func (db *TeeDB) CmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool, error) {
+	v, swapped, err := db.primary.CmpAndSwap(bucket, key, oldValue, newValue)
+	if err != nil || !swapped {
+		return v, swapped, err
+	}
+	return v, swapped, db.secondaryWrite("set", bucket, key, db.secondary.Set(bucket, key, newValue))
+}
+
+// Del deletes the key from both databases.
+func (db *TeeDB) Del(bucket, key []byte) error {
+	if err := db.primary.Del(bucket, key); err != nil {
+		return err
+	}
+	return db.secondaryWrite("delete", bucket, key, db.secondary.Del(bucket, key))
+}
+
+// List returns the entries in the primary database.
+func (db *TeeDB) List(bucket []byte) ([]*Entry, error) {
+	return db.primary.List(bucket)
+}
+
+// Update runs the transaction on the primary database, and then applies its
+// writes to the secondary database in a new transaction. Compare and swap
+// operations are applied as writes if they swapped the value on the primary.
+func (db *TeeDB) Update(tx *Tx) error {
+	if err := db.primary.Update(tx); err != nil {
+		return err
+	}
+
+	mirror := new(Tx)
+	for _, q := range tx.Operations {
+		switch {
+		case q.Cmd == CreateTable:
+			mirror.CreateTable(q.Bucket)
+		case q.Cmd == DeleteTable:
+			mirror.DeleteTable(q.Bucket)
+		case q.Cmd == Set, q.Cmd == CmpAndSwap && q.Swapped:
+			mirror.Set(q.Bucket, q.Key, q.Value)
+		case q.Cmd == Delete:
+			mirror.Del(q.Bucket, q.Key)
+		}
+	}
+	if len(mirror.Operations) == 0 {
+		return nil
+	}
+	return db.secondaryWrite("update", nil, nil, db.secondary.Update(mirror))
+}
+
+// CreateTable creates the table in both databases.
+func (db *TeeDB) CreateTable(bucket []byte) error {
+	if err := db.primary.CreateTable(bucket); err != nil {
+		return err
+	}
+	return db.secondaryWrite("create-table", bucket, nil, db.secondary.CreateTable(bucket))
+}
+
+// DeleteTable deletes the table from both databases.
+func (db *TeeDB) DeleteTable(bucket []byte) error {
+	if err := db.primary.DeleteTable(bucket); err != nil {
+		return err
+	}
+	return db.secondaryWrite("delete-table", bucket, nil, db.secondary.DeleteTable(bucket))
+}
+
+// secondaryWrite reports err if it's not nil, and returns it only in
+// TeeStrictWrites mode.
+func (db *TeeDB) secondaryWrite(op string, bucket, key []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+	db.secondaryError(op, bucket, key, err)
+	if db.mode&TeeStrictWrites != 0 {
+		return err
+	}
+	return nil
+}
+
+func (db *TeeDB) secondaryError(op string, bucket, key []byte, err error) {
+	if db.OnSecondaryError != nil {
+		db.OnSecondaryError(op, bucket, key, err)
+		return
+	}
+	log.Printf("nosql: %s %s/%s failed on secondary database: %v", op, bucket, key, err)
+}
+
+func (db *TeeDB) divergence(bucket, key, primary, secondary []byte) {
+	if db.OnDivergence != nil {
+		db.OnDivergence(bucket, key, primary, secondary)
+		return
+	}
+	log.Printf("nosql: %s/%s differs between primary and secondary databases", bucket, key)
+}
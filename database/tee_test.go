@@ -0,0 +1,126 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smallstep/assert"
+)
+
+// failingWritesDB is a DB whose writes always fail.
+type failingWritesDB struct {
+	DB
+}
+
+func (db *failingWritesDB) Set(bucket, key, value []byte) error {
+	return errors.New("force")
+}
+
+func (db *failingWritesDB) Del(bucket, key []byte) error {
+	return errors.New("force")
+}
+
+func TestTeeDB_writes(t *testing.T) {
+	bucket := []byte("users")
+	primary, secondary := newMemDB(), newMemDB()
+	db := NewTeeDB(primary, secondary, 0)
+
+	assert.FatalError(t, db.CreateTable(bucket))
+	assert.FatalError(t, db.Set(bucket, []byte("mike"), []byte("malone")))
+	assert.FatalError(t, db.Set(bucket, []byte("max"), []byte("furman")))
+	_, swapped, err := db.CmpAndSwap(bucket, []byte("max"), []byte("furman"), []byte("maxey"))
+	assert.FatalError(t, err)
+	assert.True(t, swapped)
+	_, swapped, err = db.CmpAndSwap(bucket, []byte("max"), []byte("furman"), []byte("bill"))
+	assert.FatalError(t, err)
+	assert.False(t, swapped)
+	assert.FatalError(t, db.Del(bucket, []byte("mike")))
+
+	tx := new(Tx)
+	tx.Set(bucket, []byte("mariano"), []byte("cano"))
+	tx.Cas(bucket, []byte("seb"), []byte("tiedtke"))
+	tx.Get(bucket, []byte("max"))
+	assert.FatalError(t, db.Update(tx))
+
+	want, err := primary.List(bucket)
+	assert.FatalError(t, err)
+	assert.Len(t, 3, want)
+	got, err := secondary.List(bucket)
+	assert.FatalError(t, err)
+	assert.Equals(t, want, got)
+
+	assert.FatalError(t, db.DeleteTable(bucket))
+	_, err = secondary.List(bucket)
+	assert.True(t, IsErrNotFound(err))
+}
+
+func TestTeeDB_secondaryErrors(t *testing.T) {
+	bucket := []byte("users")
+	primary := newMemDB("users")
+	secondary := &failingWritesDB{DB: newMemDB("users")}
+
+	var ops []string
+	db := NewTeeDB(primary, secondary, 0)
+	db.OnSecondaryError = func(op string, bucket, key []byte, err error) {
+		ops = append(ops, op)
+	}
+	assert.FatalError(t, db.Set(bucket, []byte("mike"), []byte("malone")))
+	assert.FatalError(t, db.Del(bucket, []byte("mike")))
+	assert.Equals(t, []string{"set", "delete"}, ops)
+
+	db = NewTeeDB(primary, secondary, TeeStrictWrites)
+	db.OnSecondaryError = func(op string, bucket, key []byte, err error) {}
+	assert.Error(t, db.Set(bucket, []byte("mike"), []byte("malone")))
+	v, err := primary.Get(bucket, []byte("mike"))
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte("malone"), v)
+}
+
+func TestTeeDB_reads(t *testing.T) {
+	bucket := []byte("users")
+	primary, secondary := newMemDB("users"), newMemDB("users")
+	assert.FatalError(t, primary.Set(bucket, []byte("mike"), []byte("malone")))
+	assert.FatalError(t, secondary.Set(bucket, []byte("mike"), []byte("malone")))
+	assert.FatalError(t, primary.Set(bucket, []byte("max"), []byte("furman")))
+	assert.FatalError(t, secondary.Set(bucket, []byte("max"), []byte("maxey")))
+	assert.FatalError(t, secondary.Set(bucket, []byte("seb"), []byte("tiedtke")))
+
+	type divergence struct {
+		key, primary, secondary string
+	}
+	var diffs []divergence
+	onDivergence := func(bucket, key, primary, secondary []byte) {
+		diffs = append(diffs, divergence{string(key), string(primary), string(secondary)})
+	}
+
+	// Reads come from the primary and are not compared by default.
+	db := NewTeeDB(primary, secondary, 0)
+	db.OnDivergence = onDivergence
+	v, err := db.Get(bucket, []byte("max"))
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte("furman"), v)
+	_, err = db.Get(bucket, []byte("seb"))
+	assert.True(t, IsErrNotFound(err))
+	assert.Len(t, 0, diffs)
+
+	db = NewTeeDB(primary, secondary, TeeCompareReads)
+	db.OnDivergence = onDivergence
+	v, err = db.Get(bucket, []byte("mike"))
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte("malone"), v)
+	v, err = db.Get(bucket, []byte("max"))
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte("furman"), v)
+	_, err = db.Get(bucket, []byte("seb"))
+	assert.True(t, IsErrNotFound(err))
+	_, err = db.Get(bucket, []byte("mariano"))
+	assert.True(t, IsErrNotFound(err))
+	assert.Equals(t, []divergence{
+		{"max", "furman", "maxey"},
+		{"seb", "", "tiedtke"},
+	}, diffs)
+
+	entries, err := db.List(bucket)
+	assert.FatalError(t, err)
+	assert.Len(t, 2, entries)
+}
@@ -16,7 +16,11 @@ import (
 
 // DB is a wrapper over *sql.DB,
 type DB struct {
-	db *sql.DB
+	db             *sql.DB
+	valueValidator database.ValueValidator
+	notifier       *database.Notifier
+	// missingTableNotFound returns ErrNotFound on a Get from a missing table.
+	missingTableNotFound bool
 }
 
 // Open creates a Driver and connects to the database with the given address
@@ -28,6 +32,9 @@ func (db *DB) Open(dataSourceName string, opt ...database.Option) error {
 			return err
 		}
 	}
+	db.valueValidator = opts.ValueValidator
+	db.notifier = database.NewNotifier(opts)
+	db.missingTableNotFound = opts.MissingTableNotFound
 
 	parsedDSN, err := mysql.ParseDSN(dataSourceName)
 	if err != nil {
@@ -92,9 +99,11 @@ func (db *DB) Get(bucket, key []byte) ([]byte, error) {
 	err := db.db.QueryRow(getQry(bucket), key).Scan(&val)
 	switch {
 	case err == sql.ErrNoRows:
-		return nil, errors.Wrapf(database.ErrNotFound, "%s/%s not found", bucket, key)
+		return nil, &database.OpError{Op: "get", Bucket: bucket, Key: key, Err: database.ErrNotFound}
+	case err != nil && db.missingTableNotFound && strings.HasPrefix(err.Error(), "Error 1146"):
+		return nil, &database.OpError{Op: "get", Bucket: bucket, Key: key, Err: errors.Wrap(database.ErrNotFound, "table does not exist")}
 	case err != nil:
-		return nil, errors.Wrapf(err, "failed to get %s/%s", bucket, key)
+		return nil, &database.OpError{Op: "get", Bucket: bucket, Key: key, Err: errors.WithStack(err)}
 	default:
 		return []byte(val), nil
 	}
@@ -102,17 +111,25 @@ func (db *DB) Get(bucket, key []byte) ([]byte, error) {
 
 // Set inserts the key and value into the given bucket(column).
 func (db *DB) Set(bucket, key, value []byte) error {
+	if err := db.valueValidator.Validate(bucket, key, value); err != nil {
+		return &database.OpError{Op: "set", Bucket: bucket, Key: key, Err: err}
+	}
 	_, err := db.db.Exec(insertUpdateQry(bucket), key, value, value)
 	if err != nil {
-		return errors.Wrapf(err, "failed to set %s/%s", bucket, key)
+		return &database.OpError{Op: "set", Bucket: bucket, Key: key, Err: errors.WithStack(err)}
 	}
+	db.notifier.Notify(database.Set, bucket, key, value)
 	return nil
 }
 
 // Del deletes a row from the database.
 func (db *DB) Del(bucket, key []byte) error {
 	_, err := db.db.Exec(delQry(bucket), key)
-	return errors.Wrapf(err, "failed to delete %s/%s", bucket, key)
+	if err != nil {
+		return &database.OpError{Op: "delete", Bucket: bucket, Key: key, Err: errors.WithStack(err)}
+	}
+	db.notifier.Notify(database.Delete, bucket, key, nil)
+	return nil
 }
 
 // List returns the full list of entries in a column.
@@ -121,9 +138,9 @@ func (db *DB) List(bucket []byte) ([]*database.Entry, error) {
 	if err != nil {
 		estr := err.Error()
 		if strings.HasPrefix(estr, "Error 1146") {
-			return nil, errors.Wrapf(database.ErrNotFound, estr)
+			return nil, &database.OpError{Op: "list", Bucket: bucket, Err: errors.Wrapf(database.ErrNotFound, estr)}
 		}
-		return nil, errors.Wrapf(err, "error querying table %s", bucket)
+		return nil, &database.OpError{Op: "list", Bucket: bucket, Err: errors.Wrap(err, "error querying table")}
 	}
 	defer rows.Close()
 	var (
@@ -133,7 +150,7 @@ func (db *DB) List(bucket []byte) ([]*database.Entry, error) {
 	for rows.Next() {
 		err := rows.Scan(&key, &value)
 		if err != nil {
-			return nil, errors.Wrap(err, "error getting key and value from row")
+			return nil, &database.OpError{Op: "list", Bucket: bucket, Err: errors.Wrap(err, "error getting key and value from row")}
 		}
 		entries = append(entries, &database.Entry{
 			Bucket: bucket,
@@ -143,7 +160,7 @@ func (db *DB) List(bucket []byte) ([]*database.Entry, error) {
 	}
 	err = rows.Err()
 	if err != nil {
-		return nil, errors.Wrap(err, "error accessing row")
+		return nil, &database.OpError{Op: "list", Bucket: bucket, Err: errors.Wrap(err, "error accessing row")}
 	}
 	return entries, nil
 }
@@ -151,6 +168,21 @@ func (db *DB) List(bucket []byte) ([]*database.Entry, error) {
 // CmpAndSwap modifies the value at the given bucket and key (to newValue)
 // only if the existing (current) value matches oldValue.
 func (db *DB) CmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool, error) {
+	if err := db.valueValidator.Validate(bucket, key, newValue); err != nil {
+		return nil, false, &database.OpError{Op: "cmpandswap", Bucket: bucket, Key: key, Err: err}
+	}
+	val, swapped, err := db.cmpAndSwap(bucket, key, oldValue, newValue)
+	if err != nil {
+		return nil, false, &database.OpError{Op: "cmpandswap", Bucket: bucket, Key: key, Err: err}
+	}
+	if swapped {
+		db.notifier.Notify(database.CmpAndSwap, bucket, key, newValue)
+	}
+	return val, swapped, nil
+}
+
+// cmpAndSwap is a helper for the CmpAndSwap method.
+func (db *DB) cmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool, error) {
 	sqlTx, err := db.db.Begin()
 	if err != nil {
 		return nil, false, errors.WithStack(err)
@@ -160,7 +192,7 @@ func (db *DB) CmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool,
 	switch {
 	case err != nil:
 		if err := sqlTx.Rollback(); err != nil {
-			return nil, false, errors.Wrapf(err, "failed to execute CmpAndSwap transaction on %s/%s and failed to rollback transaction", bucket, key)
+			return nil, false, errors.Wrap(err, "failed to execute CmpAndSwap transaction and failed to rollback transaction")
 		}
 		return nil, false, err
 	case swapped:
@@ -170,7 +202,7 @@ func (db *DB) CmpAndSwap(bucket, key, oldValue, newValue []byte) ([]byte, bool,
 		return val, swapped, nil
 	default:
 		if err := sqlTx.Rollback(); err != nil {
-			return nil, false, errors.Wrapf(err, "failed to rollback read-only CmpAndSwap transaction on %s/%s", bucket, key)
+			return nil, false, errors.Wrap(err, "failed to rollback read-only CmpAndSwap transaction")
 		}
 		return val, swapped, err
 	}
@@ -188,7 +220,7 @@ func cmpAndSwap(sqlTx *sql.Tx, bucket, key, oldValue, newValue []byte) ([]byte,
 	}
 
 	if _, err = sqlTx.Exec(insertUpdateQry(bucket), key, newValue, newValue); err != nil {
-		return nil, false, errors.Wrapf(err, "failed to set %s/%s", bucket, key)
+		return nil, false, errors.Wrap(err, "failed to set key")
 	}
 	return newValue, true, nil
 }
@@ -211,51 +243,58 @@ func (db *DB) Update(tx *database.Tx) error {
 		case database.CreateTable:
 			_, err := sqlTx.Exec(createTableQry(q.Bucket))
 			if err != nil {
-				return rollback(errors.Wrapf(err, "failed to create table %s", q.Bucket))
+				return rollback(database.TxOpError(q, errors.WithStack(err)))
 			}
 		case database.DeleteTable:
 			_, err := sqlTx.Exec(deleteTableQry(q.Bucket))
 			if err != nil {
 				estr := err.Error()
 				if strings.HasPrefix(err.Error(), "Error 1051") {
-					return errors.Wrapf(database.ErrNotFound, estr)
+					return database.TxOpError(q, errors.Wrapf(database.ErrNotFound, estr))
 				}
-				return errors.Wrapf(err, "failed to delete table %s", q.Bucket)
+				return database.TxOpError(q, errors.WithStack(err))
 			}
 		case database.Get:
 			var val string
 			err := sqlTx.QueryRow(getQry(q.Bucket), q.Key).Scan(&val)
 			switch {
 			case err == sql.ErrNoRows:
-				return rollback(errors.Wrapf(database.ErrNotFound, "%s/%s not found", q.Bucket, q.Key))
+				return rollback(database.TxOpError(q, database.ErrNotFound))
 			case err != nil:
-				return rollback(errors.Wrapf(err, "failed to get %s/%s", q.Bucket, q.Key))
+				return rollback(database.TxOpError(q, errors.WithStack(err)))
 			default:
 				q.Result = []byte(val)
 			}
 		case database.Set:
+			if err = db.valueValidator.Validate(q.Bucket, q.Key, q.Value); err != nil {
+				return rollback(database.TxOpError(q, err))
+			}
 			if _, err = sqlTx.Exec(insertUpdateQry(q.Bucket), q.Key, q.Value, q.Value); err != nil {
-				return rollback(errors.Wrapf(err, "failed to set %s/%s", q.Bucket, q.Key))
+				return rollback(database.TxOpError(q, errors.WithStack(err)))
 			}
 		case database.Delete:
 			if _, err = sqlTx.Exec(delQry(q.Bucket), q.Key); err != nil {
-				return rollback(errors.Wrapf(err, "failed to delete %s/%s", q.Bucket, q.Key))
+				return rollback(database.TxOpError(q, errors.WithStack(err)))
 			}
 		case database.CmpAndSwap:
+			if err = db.valueValidator.Validate(q.Bucket, q.Key, q.Value); err != nil {
+				return rollback(database.TxOpError(q, err))
+			}
 			q.Result, q.Swapped, err = cmpAndSwap(sqlTx, q.Bucket, q.Key, q.CmpValue, q.Value)
 			if err != nil {
-				return rollback(errors.Wrapf(err, "failed to load-or-store %s/%s", q.Bucket, q.Key))
+				return rollback(database.TxOpError(q, err))
 			}
 		case database.CmpOrRollback:
-			return database.ErrOpNotSupported
+			return database.TxOpError(q, database.ErrOpNotSupported)
 		default:
-			return database.ErrOpNotSupported
+			return database.TxOpError(q, database.ErrOpNotSupported)
 		}
 	}
 
 	if err = errors.WithStack(sqlTx.Commit()); err != nil {
 		return rollback(err)
 	}
+	db.notifier.NotifyTx(tx)
 	return nil
 }
 
@@ -263,7 +302,7 @@ func (db *DB) Update(tx *database.Tx) error {
 func (db *DB) CreateTable(bucket []byte) error {
 	_, err := db.db.Exec(createTableQry(bucket))
 	if err != nil {
-		return errors.Wrapf(err, "failed to create table %s", bucket)
+		return &database.OpError{Op: "create-table", Bucket: bucket, Err: errors.WithStack(err)}
 	}
 	return nil
 }
@@ -274,9 +313,9 @@ func (db *DB) DeleteTable(bucket []byte) error {
 	if err != nil {
 		estr := err.Error()
 		if strings.HasPrefix(estr, "Error 1051") {
-			return errors.Wrapf(database.ErrNotFound, estr)
+			return &database.OpError{Op: "delete-table", Bucket: bucket, Err: errors.Wrapf(database.ErrNotFound, estr)}
 		}
-		return errors.Wrapf(err, "failed to delete table %s", bucket)
+		return &database.OpError{Op: "delete-table", Bucket: bucket, Err: errors.WithStack(err)}
 	}
 	return nil
 }
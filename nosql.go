@@ -18,6 +18,27 @@ type Option = database.Option
 // DB is just a wrapper over database.DB.
 type DB = database.DB
 
+// Loader is just a wrapper over database.Loader.
+type Loader = database.Loader
+
+// AuditEvent is just a wrapper over database.AuditEvent.
+type AuditEvent = database.AuditEvent
+
+// AuditSink is just a wrapper over database.AuditSink.
+type AuditSink = database.AuditSink
+
+// BufferedAuditSink is just a wrapper over database.BufferedAuditSink.
+type BufferedAuditSink = database.BufferedAuditSink
+
+// ChangeEvent is just a wrapper over database.ChangeEvent.
+type ChangeEvent = database.ChangeEvent
+
+// TeeDB is just a wrapper over database.TeeDB.
+type TeeDB = database.TeeDB
+
+// TeeMode is just a wrapper over database.TeeMode.
+type TeeMode = database.TeeMode
+
 // Compactor in an interface implemented by those databases that can run a value
 // log garbage collector like badger.
 type Compactor interface {
@@ -31,10 +52,44 @@ var (
 	WithDatabase = database.WithDatabase
 	// WithBadgerFileLoadingMode is a wrapper over database.WithBadgerFileLoadingMode.
 	WithBadgerFileLoadingMode = database.WithBadgerFileLoadingMode
+	// WithValueValidator is a wrapper over database.WithValueValidator.
+	WithValueValidator = database.WithValueValidator
+	// WithAuditSink is a wrapper over database.WithAuditSink.
+	WithAuditSink = database.WithAuditSink
+	// NewBufferedAuditSink is a wrapper over database.NewBufferedAuditSink.
+	NewBufferedAuditSink = database.NewBufferedAuditSink
+	// WithChangeHook is a wrapper over database.WithChangeHook.
+	WithChangeHook = database.WithChangeHook
+	// AsyncChangeHook is a wrapper over database.AsyncChangeHook.
+	AsyncChangeHook = database.AsyncChangeHook
+	// WithMissingTableAsNotFound is a wrapper over database.WithMissingTableAsNotFound.
+	WithMissingTableAsNotFound = database.WithMissingTableAsNotFound
 	// IsErrNotFound is a wrapper over database.IsErrNotFound.
 	IsErrNotFound = database.IsErrNotFound
 	// IsErrOpNotSupported is a wrapper over database.IsErrOpNotSupported.
 	IsErrOpNotSupported = database.IsErrOpNotSupported
+	// IsErrExists is a wrapper over database.IsErrExists.
+	IsErrExists = database.IsErrExists
+	// NewTeeDB is a wrapper over database.NewTeeDB.
+	NewTeeDB = database.NewTeeDB
+	// CopyKey is a wrapper over database.CopyKey.
+	CopyKey = database.CopyKey
+	// GetOrDefault is a wrapper over database.GetOrDefault.
+	GetOrDefault = database.GetOrDefault
+	// BatchGetPartial is a wrapper over database.BatchGetPartial.
+	BatchGetPartial = database.BatchGetPartial
+	// ListMulti is a wrapper over database.ListMulti.
+	ListMulti = database.ListMulti
+	// ExportCSV is a wrapper over database.ExportCSV.
+	ExportCSV = database.ExportCSV
+	// SnapshotToWriter is a wrapper over database.SnapshotToWriter.
+	SnapshotToWriter = database.SnapshotToWriter
+	// RestoreFromReader is a wrapper over database.RestoreFromReader.
+	RestoreFromReader = database.RestoreFromReader
+	// BucketDigest is a wrapper over database.BucketDigest.
+	BucketDigest = database.BucketDigest
+	// RepairKeys is a wrapper over database.RepairKeys.
+	RepairKeys = database.RepairKeys
 
 	// Available db driver types. //
 
@@ -57,6 +112,13 @@ var (
 	BadgerMemoryMap = database.BadgerMemoryMap
 	// BadgerFileIO indicates the FileIO FileLoadingMode option.
 	BadgerFileIO = database.BadgerFileIO
+
+	// TeeDB modes
+
+	// TeeCompareReads indicates the TeeCompareReads TeeMode option.
+	TeeCompareReads = database.TeeCompareReads
+	// TeeStrictWrites indicates the TeeStrictWrites TeeMode option.
+	TeeStrictWrites = database.TeeStrictWrites
 )
 
 // New returns a database with the given driver.
@@ -2,8 +2,10 @@ package nosql
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/smallstep/assert"
@@ -19,7 +21,10 @@ func run(t *testing.T, db database.DB) {
 	var boogers = []byte("boogers")
 
 	ub := []byte("testNoSQLUsers")
-	assert.True(t, IsErrNotFound(db.DeleteTable(ub)))
+	err := db.DeleteTable(ub)
+	assert.True(t, IsErrNotFound(err))
+	assertOpError(t, err, "delete-table", ub, nil)
+	assertOpError(t, db.CreateTable([]byte{}), "create-table", []byte{}, nil)
 	assert.Nil(t, db.CreateTable(ub))
 	// Verify that re-creating the table does not cause a "table already exists" error
 	assert.Nil(t, db.CreateTable(ub))
@@ -28,8 +33,9 @@ func run(t *testing.T, db database.DB) {
 	illName := []byte("test-special-char")
 	assert.Nil(t, db.CreateTable(illName))
 	assert.Nil(t, db.DeleteTable(illName))
-	_, err := db.List(illName)
+	_, err = db.List(illName)
 	assert.True(t, IsErrNotFound(err))
+	assertOpError(t, err, "list", illName, nil)
 
 	// List should be empty
 	entries, err := db.List(ub)
@@ -39,6 +45,8 @@ func run(t *testing.T, db database.DB) {
 	// check for mike - should not exist
 	_, err = db.Get(ub, []byte("mike"))
 	assert.True(t, IsErrNotFound(err))
+	assertOpError(t, err, "get", ub, []byte("mike"))
+	assert.Equals(t, 1, strings.Count(err.Error(), string(ub)))
 
 	// add mike
 	assert.Nil(t, db.Set(ub, []byte("mike"), boogers))
@@ -94,6 +102,10 @@ func run(t *testing.T, db database.DB) {
 	// delete mike
 	assert.FatalError(t, db.Del(ub, []byte("mike")))
 
+	// CmpAndSwap should fail on an invalid bucket
+	_, _, err = db.CmpAndSwap([]byte{}, []byte("mike"), nil, mikeb)
+	assertOpError(t, err, "cmpandswap", []byte{}, []byte("mike"))
+
 	// Update //
 
 	// create txns for update test
@@ -161,6 +173,17 @@ func run(t *testing.T, db database.DB) {
 	tx := &database.Tx{Operations: []*database.TxEntry{setMike, setMariano, readMike, setSeb, readSeb, casGates, casGates2, casGates3}}
 	assert.Nil(t, db.Update(tx))
 
+	// update: a failing operation is reported.
+	readMax := &database.TxEntry{
+		Bucket: ub,
+		Key:    []byte("max"),
+		Cmd:    database.Get,
+	}
+	err = db.Update(&database.Tx{Operations: []*database.TxEntry{readMike, readMax}})
+	assert.True(t, IsErrNotFound(err))
+	assertOpError(t, err, "get", ub, []byte("max"))
+	assert.Equals(t, 1, strings.Count(err.Error(), string(ub)))
+
 	// verify that mike is in db
 	res, err = db.Get(ub, []byte("mike"))
 	assert.FatalError(t, err)
@@ -268,6 +291,31 @@ func run(t *testing.T, db database.DB) {
 	assert.True(t, IsErrNotFound(err))
 }
 
+// runMissingTable checks a Get from a table that does not exist on a database
+// opened with the default options and on one opened with
+// WithMissingTableAsNotFound.
+func runMissingTable(t *testing.T, db, notFoundDB database.DB) {
+	missing := []byte("testNoSQLMissingTable")
+	_, err := db.Get(missing, []byte("mike"))
+	assert.Error(t, err)
+	assert.False(t, IsErrNotFound(err))
+
+	_, err = notFoundDB.Get(missing, []byte("mike"))
+	assert.True(t, IsErrNotFound(err))
+}
+
+// assertOpError checks that err is an OpError for the given operation, bucket
+// and key.
+func assertOpError(t *testing.T, err error, op string, bucket, key []byte) {
+	t.Helper()
+	var opErr *database.OpError
+	if assert.True(t, errors.As(err, &opErr)) {
+		assert.Equals(t, op, opErr.Op)
+		assert.Equals(t, bucket, opErr.Bucket)
+		assert.Equals(t, key, opErr.Key)
+	}
+}
+
 func TestMain(m *testing.M) {
 
 	// setup
@@ -308,6 +356,14 @@ func TestMySQL(t *testing.T) {
 	defer db.Close()
 
 	run(t, db)
+
+	notFoundDB, err := New("mysql",
+		fmt.Sprintf("%s:%s@%s(%s)/", uname, pwd, proto, addr),
+		WithDatabase(testDB), WithMissingTableAsNotFound(true))
+	assert.FatalError(t, err)
+	defer notFoundDB.Close()
+
+	runMissingTable(t, db, notFoundDB)
 }
 
 func TestPostgreSQL(t *testing.T) {
@@ -332,6 +388,14 @@ func TestPostgreSQL(t *testing.T) {
 	defer db.Close()
 
 	run(t, db)
+
+	notFoundDB, err := New("postgresql",
+		fmt.Sprintf("postgresql://%s:%s@%s/", uname, pwd, addr),
+		WithDatabase(testDB), WithMissingTableAsNotFound(true))
+	assert.FatalError(t, err)
+	defer notFoundDB.Close()
+
+	runMissingTable(t, db, notFoundDB)
 }
 
 func TestBadger(t *testing.T) {
@@ -357,3 +421,161 @@ func TestBolt(t *testing.T) {
 
 	run(t, db)
 }
+
+func validateJSON(bucket, key, value []byte) error {
+	if !json.Valid(value) {
+		return fmt.Errorf("value of %s/%s is not valid JSON", bucket, key)
+	}
+	return nil
+}
+
+func runValueValidator(t *testing.T, db database.DB) {
+	ub := []byte("testNoSQLValidator")
+	assert.Nil(t, db.CreateTable(ub))
+
+	// Set
+	err := db.Set(ub, []byte("mike"), []byte("boogers"))
+	assert.Error(t, err)
+	assert.Equals(t, "set testNoSQLValidator/mike: value of testNoSQLValidator/mike is not valid JSON", err.Error())
+	_, err = db.Get(ub, []byte("mike"))
+	assert.True(t, IsErrNotFound(err))
+	assert.Nil(t, db.Set(ub, []byte("mike"), []byte(`{"name":"mike"}`)))
+
+	// CmpAndSwap
+	_, swapped, err := db.CmpAndSwap(ub, []byte("mike"), []byte(`{"name":"mike"}`), []byte("boogers"))
+	assert.Error(t, err)
+	assert.Equals(t, "cmpandswap testNoSQLValidator/mike: value of testNoSQLValidator/mike is not valid JSON", err.Error())
+	assert.False(t, swapped)
+	_, swapped, err = db.CmpAndSwap(ub, []byte("mike"), []byte(`{"name":"mike"}`), []byte(`{"name":"max"}`))
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+
+	// Update
+	tx := &database.Tx{}
+	tx.Set(ub, []byte("mariano"), []byte(`{"name":"mariano"}`))
+	tx.Set(ub, []byte("seb"), []byte("boogers"))
+	err = db.Update(tx)
+	assert.Error(t, err)
+	assert.Equals(t, "set testNoSQLValidator/seb: value of testNoSQLValidator/seb is not valid JSON", err.Error())
+	_, err = db.Get(ub, []byte("mariano"))
+	assert.True(t, IsErrNotFound(err))
+
+	tx = &database.Tx{Operations: []*database.TxEntry{{
+		Bucket:   ub,
+		Key:      []byte("mike"),
+		CmpValue: []byte(`{"name":"max"}`),
+		Value:    []byte("boogers"),
+		Cmd:      database.CmpAndSwap,
+	}}}
+	err = db.Update(tx)
+	assert.Error(t, err)
+	assert.Equals(t, "cmpandswap testNoSQLValidator/mike: value of testNoSQLValidator/mike is not valid JSON", err.Error())
+
+	res, err := db.Get(ub, []byte("mike"))
+	assert.FatalError(t, err)
+	assert.Equals(t, []byte(`{"name":"max"}`), res)
+
+	assert.Nil(t, db.DeleteTable(ub))
+}
+
+func TestValueValidator(t *testing.T) {
+	t.Run("badger", func(t *testing.T) {
+		path := "./tmp/badgerdb-validator"
+		assert.FatalError(t, os.MkdirAll(path, 0755))
+
+		db, err := New("badger", path, WithValueDir(path), WithValueValidator(validateJSON))
+		assert.FatalError(t, err)
+		defer db.Close()
+
+		runValueValidator(t, db)
+	})
+
+	t.Run("bbolt", func(t *testing.T) {
+		assert.FatalError(t, os.MkdirAll("./tmp", 0644))
+
+		db, err := New("bbolt", "./tmp/boltdb-validator", WithValueValidator(validateJSON))
+		assert.FatalError(t, err)
+		defer db.Close()
+
+		runValueValidator(t, db)
+	})
+}
+
+type notificationRecorder struct {
+	events  []database.AuditEvent
+	changes []database.ChangeEvent
+}
+
+func (r *notificationRecorder) Record(e database.AuditEvent) {
+	r.events = append(r.events, e)
+}
+
+func (r *notificationRecorder) ChangeHook(e database.ChangeEvent) {
+	r.changes = append(r.changes, e)
+}
+
+func runNotifications(t *testing.T, db database.DB, r *notificationRecorder) {
+	ub := []byte("testNoSQLNotifications")
+	assert.Nil(t, db.CreateTable(ub))
+
+	assert.Nil(t, db.Set(ub, []byte("mike"), []byte("boogers")))
+	_, err := db.Get(ub, []byte("mike"))
+	assert.Nil(t, err)
+	_, swapped, err := db.CmpAndSwap(ub, []byte("mike"), []byte("boogers"), []byte("malone"))
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	_, swapped, err = db.CmpAndSwap(ub, []byte("mike"), []byte("boogers"), []byte("maxey"))
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+	assert.Nil(t, db.Del(ub, []byte("mike")))
+
+	tx := &database.Tx{}
+	tx.Set(ub, []byte("mariano"), []byte("cano"))
+	tx.Get(ub, []byte("mariano"))
+	tx.Del(ub, []byte("mariano"))
+	assert.Nil(t, db.Update(tx))
+
+	want := []database.ChangeEvent{
+		{Op: database.Set, Bucket: ub, Key: []byte("mike"), Value: []byte("boogers")},
+		{Op: database.CmpAndSwap, Bucket: ub, Key: []byte("mike"), Value: []byte("malone")},
+		{Op: database.Delete, Bucket: ub, Key: []byte("mike")},
+		{Op: database.Set, Bucket: ub, Key: []byte("mariano"), Value: []byte("cano")},
+		{Op: database.Delete, Bucket: ub, Key: []byte("mariano")},
+	}
+	assert.Equals(t, want, r.changes)
+	if assert.Len(t, len(want), r.events) {
+		for i, w := range want {
+			assert.Equals(t, w.Bucket, r.events[i].Bucket)
+			assert.Equals(t, w.Key, r.events[i].Key)
+			assert.Equals(t, w.Op, r.events[i].Op)
+			assert.False(t, r.events[i].Time.IsZero())
+		}
+	}
+
+	assert.Nil(t, db.DeleteTable(ub))
+}
+
+func TestNotifications(t *testing.T) {
+	t.Run("badger", func(t *testing.T) {
+		path := "./tmp/badgerdb-notifications"
+		assert.FatalError(t, os.MkdirAll(path, 0755))
+
+		r := &notificationRecorder{}
+		db, err := New("badger", path, WithValueDir(path), WithAuditSink(r), WithChangeHook(r.ChangeHook))
+		assert.FatalError(t, err)
+		defer db.Close()
+
+		runNotifications(t, db, r)
+	})
+
+	t.Run("bbolt", func(t *testing.T) {
+		assert.FatalError(t, os.MkdirAll("./tmp", 0644))
+
+		r := &notificationRecorder{}
+		db, err := New("bbolt", "./tmp/boltdb-notifications", WithAuditSink(r), WithChangeHook(r.ChangeHook))
+		assert.FatalError(t, err)
+		defer db.Close()
+
+		runNotifications(t, db, r)
+	})
+}